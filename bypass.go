@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// URL path variant used to bypass case sensitive rules and naive WAF/ACL rules
+type urlVariant struct {
+	upath string
+	kind  string // "case" or "encode"
+}
+
+// Server case sensitivity detected from responses
+const caseUnknown = 0
+const caseSensitive = 1
+const caseInsensitive = 2
+
+// Generate variants of URL path (only filename part is changed)
+// Case variants are dropped once server is known to be case-insensitive
+func urlPathVariants(upath string, serverCase int) []urlVariant {
+	if !argCaseVariants && !argEncodeVariants {
+		return nil
+	}
+
+	dir, name := path.Split(strings.TrimSuffix(upath, "/"))
+	if name == "" {
+		return nil
+	}
+
+	var variants []urlVariant
+	seen := map[string]bool{upath: true}
+	add := func(kind, v string) {
+		if !seen[v] {
+			seen[v] = true
+			variants = append(variants, urlVariant{v, kind})
+		}
+	}
+
+	if argCaseVariants && serverCase != caseInsensitive {
+		add("case", dir+strings.ToUpper(name)) // first - used to detect case sensitivity
+		add("case", dir+strings.ToLower(name))
+		add("case", dir+strings.Title(strings.ToLower(name))) // Web.Config
+		add("case", dir+strings.ToUpper(name[:1])+name[1:])   // Web.config
+	}
+
+	if argEncodeVariants {
+		add("encode", dir+strings.Replace(name, ".", "%2e", -1))
+		add("encode", dir+strings.Replace(name, ".", "%252e", -1)) // double encoded
+		if dir != "" {
+			add("encode", strings.TrimSuffix(dir, "/")+"%2f"+name)
+			add("encode", strings.TrimSuffix(dir, "/")+";/"+name)
+		} else {
+			add("encode", ";/"+name)
+		}
+		add("encode", dir+name+"/.")
+		add("encode", dir+name+"%20")
+		add("encode", dir+name+".")
+		add("encode", dir+name+"::$DATA") // IIS alternate data stream
+	}
+
+	return variants
+}
+
+// Check variants of URL path after original was checked
+// Uppercase variant giving the same response as original hit means case-insensitive server
+func checkURLVariants(t *target, c candidate, original *scanResult) {
+	for _, v := range urlPathVariants(c.fpath, t.serverCase) {
+		if v.kind == "case" && t.serverCase == caseInsensitive {
+			continue // detected while checking previous variants
+		}
+
+		vc := c
+		vc.fpath = v.upath
+		vc.note = strings.TrimSpace(fmt.Sprintf("%s [%s VARIANT]", c.note, strings.ToUpper(v.kind)))
+		result := checkURL(t, vc)
+
+		// Case sensitivity detection on hits only
+		if v.kind != "case" || t.serverCase != caseUnknown || original == nil || result.Err != nil || original.Err != nil {
+			continue
+		}
+		originalHit := !original.Skipped && original.Code >= 200 && original.Code < 300
+		switch {
+		case originalHit && result.Code == original.Code && result.Size == original.Size:
+			t.serverCase = caseInsensitive
+			t.log(color.CyanString("-- %s: server is case-insensitive. Case variants are not checked anymore --", t.endpoint))
+		case originalHit && result.Code != original.Code:
+			t.serverCase = caseSensitive
+		}
+	}
+}
@@ -3,11 +3,10 @@ package main
 import (
 	"fmt"
 	"math"
-	"net/url"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/integrii/flaggy"
@@ -22,37 +21,109 @@ func parseArgs() {
 	flaggy.DefaultParser.AdditionalHelpPrepend += strings.Repeat(".", 80)
 
 	// add a global bool flag for fun
-	flaggy.String(&argSourcePath, "s", "src", "Source path of directory -- REQUIRED")
-	flaggy.String(&argEndpoint, "u", "url", "URL endpoint to hit -- REQUIRED")
+	flaggy.String(&argSourcePath, "s", "src", "Source path of directory, .zip, .tar, .tar.gz or git repository -- REQUIRED")
+	flaggy.String(&argSourceRef, "", "ref", "Git ref (tag, branch, commit) of source repository. No checkout needed")
+	flaggy.String(&argImagePath, "", "image-path", "Path inside `docker save` image used as source root (e.g. /var/www/html)")
+	flaggy.String(&argWordlist, "w", "wordlist", "Wordlist with one relative path per line. Use '-' for stdin")
+	flaggy.String(&argPathsList, "", "paths", "List of paths or URLs (e.g. from previous crawl). Use '-' for stdin")
+	flaggy.StringSlice(&argEndpoints, "u", "url", "URL endpoint to hit (repeatable) -- REQUIRED unless `--targets` given")
+	flaggy.String(&argTargetsFile, "", "targets", "File of URL endpoints, one per line with optional delay in ms (\"https://a.xx/ 500\")")
+	flaggy.Int(&argConcurrency, "", "concurrency", "Hosts scanned in parallel (one request at a time per host)")
 	flaggy.String(&argMethod, "m", "method", "HTTP Method to use")
 	flaggy.String(&argReportPath, "o", "output", "Output report to file")
+	flaggy.StringSlice(&argWebRootMaps, "", "map", "Map source subtree to URL prefix (e.g. public/=/). Use 'auto' to detect web root")
+	flaggy.Bool(&argTLSVerify, "", "tls-verify", "Verify server TLS certificate")
+	flaggy.String(&argCACert, "", "ca-cert", "CA bundle (PEM) used to verify server certificate (added to system CAs). Implies `--tls-verify`")
+	flaggy.String(&argClientCert, "", "client-cert", "Client certificate (PEM) for mTLS")
+	flaggy.String(&argClientKey, "", "client-key", "Client certificate key (PEM) for mTLS")
+	flaggy.String(&argSNI, "", "sni", "Server name sent in TLS handshake (SNI) and verified in certificate instead of URL host")
+	flaggy.String(&argTLSMinVersion, "", "tls-min", "Minimum TLS version (1.0, 1.1, 1.2, 1.3)")
+	flaggy.StringSlice(&argResolve, "", "resolve", "Pin host to IP like curl (host:port:ip)")
+	flaggy.StringSlice(&argVhosts, "", "vhost", "Check every path with this Host header (vhost mode). Results compared per vhost")
+	flaggy.String(&argVhostsFile, "", "vhosts-file", "File with Host header values (one per line) for vhost mode")
+	flaggy.String(&argFollowRedirects, "", "follow-redirects", "Follow redirects: none, same-host, all")
+	flaggy.Int(&argMaxRedirects, "", "max-redirects", "Max redirect hops followed")
+	flaggy.Int(&argMaxBody, "", "max-body", "Max bytes of response body read. Requested with 'Range: bytes=0-N' header. '0' no limit")
+	flaggy.Bool(&argHeadFallback, "", "head-fallback", "Use GET with small Range if server answers HEAD differently than GET")
+	flaggy.Bool(&argCrossCheck, "", "cross-check", "Check every hit with other method (HEAD/GET) and report differences")
 	flaggy.Int(&argDepth, "", "depth", "How deep go in folders. '0' no limit ")
 	flaggy.Int(&argDelay, "z", "delay", "Delay every request for N milliseconds")
 	flaggy.Int(&argTimeout, "", "timeout", "Timeout (seconds) to wait for response ")
 	flaggy.StringSlice(&argBackups, "", "mutations", "Mutations of checked file")
+	flaggy.String(&argMutationRules, "", "mutation-rules", "File with mutation rules (hashcat-like rule language) applied to filenames")
+	flaggy.StringSlice(&argDirMutations, "", "dir-mutations", "Mutations of checked directory (archives next to it, dumps inside it)")
+	flaggy.String(&argDateRange, "", "date-range", "Dates for {date} mutation placeholder (e.g. 2019-09-01..2019-09-30). Default: file modification date")
+	flaggy.String(&argTemplateRules, "", "template-rules", "File with rules deriving real filename from template (e.g. *.example => *)")
+	flaggy.String(&argRelatedDB, "", "related-db", "Additional related files database (YAML) appended to embedded one")
+	flaggy.Bool(&argCaseVariants, "", "case-variants", "Check case variants of filename (WEB.CONFIG, Web.Config)")
+	flaggy.Bool(&argEncodeVariants, "", "encode-variants", "Check encoded variants of filename (%2e, ;/, trailing /. and %20, ::$DATA, double encoding)")
+	flaggy.String(&argStatsPath, "", "stats", "File where hit-rate of mutations is collected across scans. Empty to disable")
+	flaggy.Bool(&argSmart, "", "smart", "Order mutations by collected hit-rate and drop ones that never hit")
 	flaggy.StringSlice(&argSkip, "", "skip", "Skip files with these extensions")
 	flaggy.StringSlice(&argSkipExts, "", "skip-ext", "Skip files with these extensions")
-	flaggy.StringSlice(&argSkipCodes, "", "skip-code", "Skip responses with this response HTTP code")
-	flaggy.StringSlice(&argSkipSizes, "", "skip-size", "Skip responses with this body size")
-	flaggy.String(&argSkipContent, "", "skip-content", "Skip responses if given content found")
+	flaggy.StringSlice(&argSkipCodes, "", "skip-code", "Skip responses with HTTP code (404, 4xx, 300-399, >=500). 404 is skipped unless code filter given")
+	flaggy.StringSlice(&argSkipSizes, "", "skip-size", "Skip responses with body size (0, 100-200, >1000, <=10)")
+	flaggy.String(&argSkipContent, "", "skip-content", "Skip responses if body matches regex")
+	flaggy.StringSlice(&argSkipHeaders, "", "skip-header", "Skip responses if header line ('Key: value') matches regex (repeatable, comma is part of regex)")
+	flaggy.StringSlice(&argSkipWords, "", "skip-words", "Skip responses with body word count (10, 10-20, >100)")
+	flaggy.StringSlice(&argSkipLines, "", "skip-lines", "Skip responses with body line count (10, 10-20, >100)")
+	flaggy.StringSlice(&argSkipTime, "", "skip-time", "Skip responses by response time in milliseconds (>1000, <100)")
+	flaggy.String(&argSkipMode, "", "skip-mode", "Skip response if 'or' any / 'and' all of skip flags match")
+	flaggy.StringSlice(&argMatchCodes, "", "match-code", "Show only responses with HTTP code (200, 2xx, 300-399)")
+	flaggy.StringSlice(&argMatchSizes, "", "match-size", "Show only responses with body size (>0, 100-200)")
+	flaggy.String(&argMatchContent, "", "match-content", "Show only responses if body matches regex")
+	flaggy.StringSlice(&argMatchHeaders, "", "match-header", "Show only responses if header line ('Key: value') matches regex (repeatable, comma is part of regex)")
+	flaggy.StringSlice(&argMatchWords, "", "match-words", "Show only responses with body word count (10, 10-20, >100)")
+	flaggy.StringSlice(&argMatchLines, "", "match-lines", "Show only responses with body line count (10, 10-20, >100)")
+	flaggy.StringSlice(&argMatchTime, "", "match-time", "Show only responses by response time in milliseconds (>1000)")
+	flaggy.String(&argMatchMode, "", "match-mode", "Show response if 'or' any / 'and' all of match flags match")
+	flaggy.Bool(&argRoutes, "", "routes", "Extract routes from framework source code and check them")
+	flaggy.Bool(&argReferences, "", "refs", "Check paths referenced in source file contents (include, import, src, href, config)")
+	flaggy.Bool(&argPriority, "", "priority", "Check the most sensitive candidates (.env, keys, dumps, configs) first instead of walk order")
+	flaggy.String(&argMinSeverity, "", "min-severity", "Show only results of this or higher severity (info, low, medium, high, critical)")
+	flaggy.StringSlice(&argFailOn, "", "fail-on", "Exit with code 1 if finding matches rule (e.g. 'severity>=high', 'status=200', 'class=credentials and status=2xx')")
+	flaggy.String(&argBaselinePath, "", "baseline", "File of accepted findings (URL and vhost) not failing `--fail-on` rules")
+	flaggy.Bool(&argBaselineUpdate, "", "baseline-update", "Write all findings of this scan to `--baseline` file")
+	flaggy.Int(&argMaxErrors, "", "max-errors", "Failed requests tolerated before exit with code 2 (only with `--fail-on`)")
 	flaggy.Bool(&argDirOnly, "D", "dir-only", "Scan directories only")
 	flaggy.String(&argUserAgent, "", "user-agent", "User-Agent used")
 	flaggy.String(&argCookieString, "C", "cookie", "Cookie string sent with requests")
 	flaggy.String(&argHeaderString, "H", "headers", "Custom Headers sent with requests")
 
+	// Subcommand to show generated mutations without scanning
+	cmdMutations := flaggy.NewSubcommand("mutations")
+	cmdMutations.Description = "Print all mutations generated for given path and what produced them"
+	cmdMutations.String(&argExplainPath, "", "explain", "Source path to explain (end with '/' for directory) -- REQUIRED")
+	flaggy.AttachSubcommand(cmdMutations, 1)
+
 	// set the version and parse all inputs into variables
 	flaggy.SetVersion(version)
 	flaggy.Parse()
 
+	// Slice flags are split on comma by flaggy, header regex ("\d{1,3}") must stay whole
+	argSkipHeaders = rawFlagValues(os.Args[1:], "skip-header")
+	argMatchHeaders = rawFlagValues(os.Args[1:], "match-header")
+
+	if cmdMutations.Used {
+		if argExplainPath == "" {
+			flaggy.ShowHelpAndExit("")
+		}
+		if err := explainMutations(argExplainPath); err != nil {
+			color.Red("\n%v\n\n", err)
+			os.Exit(exitErrors)
+		}
+		os.Exit(exitClean)
+	}
+
 	// On missing params show help
-	if argSourcePath == "" || argEndpoint == "" {
+	if (argSourcePath == "" && argWordlist == "" && argPathsList == "") || (len(argEndpoints) == 0 && argTargetsFile == "") {
 		flaggy.ShowHelpAndExit("")
 	}
 
 	// Validate
 	if err := validateArgs(); err != nil {
 		color.Red("\n%v\n\n", err)
-		return
+		os.Exit(exitErrors)
 	}
 
 	if argUserAgent == "random" || argUserAgent == "" {
@@ -92,18 +163,13 @@ func parseArgs() {
 
 	}
 
-	// Trailing slash - domain must end with slash
-	// Do not add slash if longer URL given
-	urlparts, errParse := url.Parse(argEndpoint)
-	if strings.HasSuffix(argEndpoint, urlparts.Hostname()) {
-		argEndpoint = strings.TrimSuffix(argEndpoint, "/") + "/"
-	}
-
-	// parse URL to add suffix to report filename
+	// add hostname suffix to report filename ("targets" for many targets)
 	// if custom report path given do not add suffix
 	if argReportPath != "" && argReportPath == "./findthese.report" {
-		if errParse == nil {
-			argReportPath += "." + urlparts.Hostname()
+		if len(targets) == 1 {
+			argReportPath += "." + targets[0].host
+		} else {
+			argReportPath += ".targets"
 		}
 	}
 
@@ -112,21 +178,142 @@ func parseArgs() {
 // Validate arguments
 func validateArgs() error {
 
-	// Does source path exists
-	if _, err := os.Stat(argSourcePath); os.IsNotExist(err) {
-		// path/to/whatever does not exist
-		return fmt.Errorf("Source path [-s, --src]: \n\t%v", err)
+	var sources multiSource
+
+	if argSourcePath != "" {
+		// Does source path exists
+		if _, err := os.Stat(argSourcePath); os.IsNotExist(err) {
+			// path/to/whatever does not exist
+			return fmt.Errorf("Source path [-s, --src]: \n\t%v", err)
+		}
+		argSourcePath, _ = filepath.Abs(argSourcePath)
+
+		// Source type detected by path, given git ref or image path
+		argSourceRef = strings.TrimSpace(argSourceRef)
+		argImagePath = strings.TrimSpace(argImagePath)
+		src, err := openSource(argSourcePath, argSourceRef, argImagePath)
+		if err != nil {
+			return fmt.Errorf("Source path [-s, --src]: \n\t%v", err)
+		}
+		sources = append(sources, src)
+	}
+
+	// Path lists are checked the same way as source tree
+	if argWordlist != "" {
+		src, err := newListSource("wordlist", argWordlist)
+		if err != nil {
+			return fmt.Errorf("Wordlist [-w, --wordlist]: \n\t%v", err)
+		}
+		sources = append(sources, src)
+	}
+	if argPathsList != "" {
+		if argPathsList == "-" && argWordlist == "-" {
+			return fmt.Errorf("Paths [--paths]: stdin already used by wordlist")
+		}
+		src, err := newListSource("paths", argPathsList)
+		if err != nil {
+			return fmt.Errorf("Paths [--paths]: \n\t%v", err)
+		}
+		sources = append(sources, src)
+	}
+
+	source = sources
+	if len(sources) == 1 {
+		source = sources[0]
 	}
-	argSourcePath, _ = filepath.Abs(argSourcePath)
-	argSourcePath += "/"
 
 	// NB! Do not check here if URL is available!
 	// Because of different configurations given base URL could not be "200 OK"
 	// Also there could be configurations where only valid files gives different response and others fails
 
+	// Web root mappings
+	var err error
+	if webRootMaps, err = parseWebRootMaps(argWebRootMaps, source); err != nil {
+		return fmt.Errorf("Web root mapping [--map]: \n\t%v", err)
+	}
+
+	// Dates for mutations
+	if mutationDateRange, err = parseDateRange(argDateRange); err != nil {
+		return fmt.Errorf("Date range [--date-range]: \n\t%v", err)
+	}
+
+	// Mutation rules
+	if mutationRules, err = loadMutationRules(argMutationRules); err != nil {
+		return fmt.Errorf("Mutation rules [--mutation-rules]: \n\t%v", err)
+	}
+
+	// Template to real file rules
+	if templateRules, err = loadTemplateRules(argTemplateRules); err != nil {
+		return fmt.Errorf("Template rules [--template-rules]: \n\t%v", err)
+	}
+
+	// Related files database
+	if err := loadRelatedDB(argRelatedDB); err != nil {
+		return fmt.Errorf("Related files database [--related-db]: \n\t%v", err)
+	}
+
+	// Hit-rate stats
+	argStatsPath = expandHomePath(argStatsPath)
+	if argSmart && argStatsPath == "" {
+		return fmt.Errorf("Smart [--smart]: \n\tneeds stats file [--stats]")
+	}
+	if err := loadStats(argStatsPath); err != nil {
+		return fmt.Errorf("Stats [--stats]: \n\t%v", err)
+	}
+
+	// Severity filter
+	if minSeverity, err = parseSeverity(argMinSeverity); err != nil {
+		return fmt.Errorf("Min severity [--min-severity]: \n\t%v", err)
+	}
+
+	// CI gating
+	if failRules, err = parseFailRules(argFailOn); err != nil {
+		return fmt.Errorf("Fail rules [--fail-on]: \n\t%v", err)
+	}
+	if argMaxErrors < 0 {
+		argMaxErrors = 0
+	}
+	if argBaselineUpdate && argBaselinePath == "" {
+		return fmt.Errorf("Baseline [--baseline-update]: \n\tneeds baseline file [--baseline]")
+	}
+	if baseline, err = loadBaseline(argBaselinePath); err != nil {
+		return fmt.Errorf("Baseline [--baseline]: \n\t%v", err)
+	}
+
 	// Method uppercase - necessary only for visual appearance
 	argMethod = strings.ToUpper(strings.TrimSpace(argMethod))
 
+	// TLS
+	// CA bundle is given only to verify server with it
+	if argCACert != "" {
+		argTLSVerify = true
+	}
+	if tlsConfig, err = buildTLSConfig(); err != nil {
+		return fmt.Errorf("TLS [--tls-verify, --ca-cert, --client-cert, --client-key, --sni, --tls-min]: \n\t%v", err)
+	}
+
+	// DNS pinning and virtual hosts
+	if resolveMap, err = parseResolve(argResolve); err != nil {
+		return fmt.Errorf("Resolve [--resolve]: \n\t%v", err)
+	}
+	if vhosts, err = loadVhosts(argVhosts, argVhostsFile); err != nil {
+		return fmt.Errorf("Virtual hosts [--vhost, --vhosts-file]: \n\t%v", err)
+	}
+
+	// Redirect policy
+	argFollowRedirects = strings.ToLower(strings.TrimSpace(argFollowRedirects))
+	if !inSlice(argFollowRedirects, []string{"none", "same-host", "all"}) {
+		return fmt.Errorf("Follow redirects [--follow-redirects]: \n\tinvalid policy [%s] (expected: none, same-host, all)", argFollowRedirects)
+	}
+	if argMaxRedirects < 0 {
+		argMaxRedirects = 0
+	}
+
+	// Body limit
+	if argMaxBody < 0 {
+		argMaxBody = 0
+	}
+
 	// Depth
 	if argDepth < 0 {
 		argDepth = 0
@@ -154,47 +341,42 @@ func validateArgs() error {
 	}
 	argSkipExts = exts
 
-	// Skiped sizes
-	var sizes []string
+	// Skip and match filters
+	argSkipCodes = normalizeArgSlice(argSkipCodes)
 	argSkipSizes = normalizeArgSlice(argSkipSizes)
-	if len(argSkipSizes) == 1 && argSkipSizes[0] == "" {
-		argSkipSizes = nil
-	}
-	for _, s := range argSkipSizes {
-
-		// Range definitions 100-200
-		if strings.Contains(s, "-") {
-			parts := strings.Split(s, "-")
-			n1, _ := strconv.Atoi(parts[0])
-			n2, _ := strconv.Atoi(parts[1])
-			n1 = int(math.Abs(float64(n1)))
-			n2 = int(math.Abs(float64(n2)))
-			if n1 > n2 {
-				n2 = n1 + 10
-			} else if n2 > n1 {
-				n1 = n2 - 10
-				if n1 < 0 {
-					n1 = 0
-				}
-			}
-			for n := n1; n <= n2; n++ {
-				sizes = append(sizes, fmt.Sprintf("%d", n))
-			}
-			continue
+	argMatchCodes = normalizeArgSlice(argMatchCodes)
+	argMatchSizes = normalizeArgSlice(argMatchSizes)
+	for _, mode := range []*string{&argSkipMode, &argMatchMode} {
+		*mode = strings.ToLower(strings.TrimSpace(*mode))
+		if *mode != "or" && *mode != "and" {
+			return fmt.Errorf("Filter mode [--skip-mode, --match-mode]: \n\tinvalid mode [%s] (expected: or, and)", *mode)
+		}
+	}
+	if skipFilters, err = buildFilters("skip", argSkipCodes, argSkipSizes, argSkipContent, argSkipHeaders, argSkipWords, argSkipLines, argSkipTime); err != nil {
+		return fmt.Errorf("Skip filter %v", err)
+	}
+	if matchFilters, err = buildFilters("match", argMatchCodes, argMatchSizes, argMatchContent, argMatchHeaders, argMatchWords, argMatchLines, argMatchTime); err != nil {
+		return fmt.Errorf("Match filter %v", err)
+	}
+	// Default code skip is not combined by `--skip-mode` (404 stays skipped with "and")
+	defaultSkipFilters = nil
+	if !hasFilter(skipFilters, "skip-code") && !hasFilter(matchFilters, "match-code") {
+		if defaultSkipFilters, err = buildFilters("skip", defaultSkipCodes, nil, "", nil, nil, nil, nil); err != nil {
+			return fmt.Errorf("Skip filter %v", err)
 		}
-
-		sizes = append(sizes, s)
 	}
-	argSkipSizes = sizes
 
 	// Can't use method `HEAD` with certains flags depending on response body
-	if argMethod == "HEAD" {
-		if argSkipSizes != nil {
-			argMethod = "GET"
-		}
-		if argSkipContent != "" {
-			argMethod = "GET"
-		}
+	if argMethod == "HEAD" && filtersNeedBody() {
+		argMethod = "GET"
+	}
+
+	// Targets (after method, body limit and delay are known)
+	if argConcurrency < 1 {
+		argConcurrency = 1
+	}
+	if targets, err = loadTargets(argEndpoints, argTargetsFile); err != nil {
+		return fmt.Errorf("Targets [-u, --url, --targets]: \n\t%v", err)
 	}
 
 	// No errors
@@ -203,19 +385,104 @@ func validateArgs() error {
 
 func printUsedArgs() {
 	fmt.Println(strings.Repeat("-", 80))
-	color.Cyan("%20s: %s", "URL", color.HiCyanString("%v", argEndpoint))
-	color.Cyan("%20s: %s", "Source path", color.HiCyanString("%v", argSourcePath))
+	if len(targets) == 1 {
+		color.Cyan("%20s: %s", "URL", color.HiCyanString("%v", targets[0].endpoint))
+	} else {
+		color.Cyan("%20s: (%d) %s", "Targets", len(targets), color.HiCyanString("%v", targetEndpoints()))
+		color.Cyan("%20s: %s", "Concurrency", color.HiCyanString("%v hosts", argConcurrency))
+	}
+	if argSourcePath != "" {
+		color.Cyan("%20s: %s", "Source path", color.HiCyanString("%v", argSourcePath))
+	}
+	if argWordlist != "" {
+		color.Cyan("%20s: %s", "Wordlist", color.HiCyanString("%v", argWordlist))
+	}
+	if argPathsList != "" {
+		color.Cyan("%20s: %s", "Paths", color.HiCyanString("%v", argPathsList))
+	}
+	color.Cyan("%20s: %s", "Source type", color.HiCyanString("%v", source.Type()))
+	if argSourceRef != "" {
+		color.Cyan("%20s: %s", "Source ref", color.HiCyanString("%v", argSourceRef))
+	}
+	if argImagePath != "" {
+		color.Cyan("%20s: %s", "Image path", color.HiCyanString("%v", argImagePath))
+	}
+	if len(argWebRootMaps) > 0 {
+		var maps []string
+		for _, m := range webRootMaps {
+			maps = append(maps, fmt.Sprintf("%s=/%s", m.srcPrefix, m.urlPrefix))
+		}
+		if len(maps) == 0 {
+			maps = append(maps, "(web root not detected - whole source used)")
+		}
+		color.Cyan("%20s: %s", "Web root mapping", color.HiCyanString("%v", strings.Join(maps, ", ")))
+	}
 	color.Cyan("%20s: %s", "Method", color.HiCyanString("%v", argMethod))
+	if strings.Contains(targetEndpoints(), "https://") {
+		color.Cyan("%20s: %s", "TLS verify", color.HiCyanString("%v", argTLSVerify))
+		if argSNI != "" {
+			color.Cyan("%20s: %s", "SNI", color.HiCyanString("%v", argSNI))
+		}
+		if argClientCert != "" {
+			color.Cyan("%20s: %s", "Client cert", color.HiCyanString("%v", argClientCert))
+		}
+	}
+	if len(argResolve) > 0 {
+		color.Cyan("%20s: %s", "Resolve", color.HiCyanString("%v", strings.Join(argResolve, ", ")))
+	}
+	if len(vhosts) > 0 {
+		color.Cyan("%20s: (%d) %s", "Virtual hosts", len(vhosts), color.HiCyanString("%v", strings.Join(vhosts, ", ")))
+	}
+	color.Cyan("%20s: %s", "Follow redirects", color.HiCyanString("%v (max %d)", argFollowRedirects, argMaxRedirects))
+	if argMaxBody > 0 {
+		color.Cyan("%20s: %s", "Max body", color.HiCyanString("%v", formatBytes(int64(argMaxBody))))
+	}
+	color.Cyan("%20s: %s", "HEAD fallback", color.HiCyanString("%v", argHeadFallback))
+	if argCrossCheck {
+		color.Cyan("%20s: %s", "Cross-check", color.HiCyanString("%v", argCrossCheck))
+	}
 	color.Cyan("%20s: %s", "Depth scan", color.HiCyanString("%v", argDepth))
+	color.Cyan("%20s: %s", "Routes", color.HiCyanString("%v", argRoutes))
+	color.Cyan("%20s: %s", "References", color.HiCyanString("%v", argReferences))
+	color.Cyan("%20s: %s", "Priority order", color.HiCyanString("%v", argPriority))
+	color.Cyan("%20s: %s", "Min severity", color.HiCyanString("%v", severityNames[minSeverity]))
+	if len(failRules) > 0 {
+		color.Cyan("%20s: (%d) %s", "Fail on", len(failRules), color.HiCyanString("%v", strings.Join(argFailOn, ", ")))
+		color.Cyan("%20s: %s", "Max errors", color.HiCyanString("%v", argMaxErrors))
+	}
+	if argBaselinePath != "" {
+		color.Cyan("%20s: (%d) %s", "Baseline", len(baseline), color.HiCyanString("%v", argBaselinePath))
+	}
 	color.Cyan("%20s: %s", "Dir only", color.HiCyanString("%v", argDirOnly))
 	color.Cyan("%20s: %s (ms)", "Delay", color.HiCyanString("%v", argDelay))
 	color.Cyan("%20s: %s (s)", "Timeout", color.HiCyanString("%v", argTimeout))
 	color.Cyan("%20s: (%d) %s", "Ignore dir/files", len(argSkip), color.HiCyanString("%v", strings.Join(argSkip, ", ")))
 	color.Cyan("%20s: (%d) %s", "Ignore extensions", len(argSkipExts), color.HiCyanString("%v", strings.Join(argSkipExts, ", ")))
-	color.Cyan("%20s: (%d) %s", "Ignore by HTTP Code", len(argSkipCodes), color.HiCyanString("%v", strings.Join(argSkipCodes, ", ")))
+	if len(defaultSkipFilters) > 0 {
+		color.Cyan("%20s: (%d) %s", "Ignore by HTTP Code", len(defaultSkipCodes), color.HiCyanString("%v (default)", strings.Join(defaultSkipCodes, ", ")))
+	} else {
+		color.Cyan("%20s: (%d) %s", "Ignore by HTTP Code", len(argSkipCodes), color.HiCyanString("%v", strings.Join(argSkipCodes, ", ")))
+	}
 	color.Cyan("%20s: (%d) %s", "Ignore by size", len(argSkipSizes), color.HiCyanString("%v", strings.Join(argSkipSizes, ", ")))
 	color.Cyan("%20s: %s", "Ignore by content", color.HiCyanString("%v", argSkipContent))
+	color.Cyan("%20s: (%d) %s", "Skip filters", len(skipFilters), color.HiCyanString("%v (%s)", filterNames(skipFilters), argSkipMode))
+	if len(matchFilters) > 0 {
+		color.Cyan("%20s: (%d) %s", "Match filters", len(matchFilters), color.HiCyanString("%v (%s)", filterNames(matchFilters), argMatchMode))
+	}
 	color.Cyan("%20s: (%d) %s", "Mutation options", len(argBackups), color.HiCyanString("%v", strings.Join(argBackups, ", ")))
+	color.Cyan("%20s: (%d) %s", "Mutation rules", len(mutationRules), color.HiCyanString("%v", argMutationRules))
+	color.Cyan("%20s: (%d) %s", "Dir mutations", len(argDirMutations), color.HiCyanString("%v", strings.Join(argDirMutations, ", ")))
+	if argDateRange != "" {
+		color.Cyan("%20s: %s", "Date range", color.HiCyanString("%v", argDateRange))
+	}
+	color.Cyan("%20s: %s", "Case variants", color.HiCyanString("%v", argCaseVariants))
+	color.Cyan("%20s: %s", "Encode variants", color.HiCyanString("%v", argEncodeVariants))
+	color.Cyan("%20s: %s", "Stats", color.HiCyanString("%v", argStatsPath))
+	if argSmart {
+		color.Cyan("%20s: %s (%d mutations dropped)", "Smart", color.HiCyanString("%v", scanProfile(scanServer)), smartPruned)
+	}
+	color.Cyan("%20s: (%d) %s", "Template rules", len(templateRules), color.HiCyanString("%v", argTemplateRules))
+	color.Cyan("%20s: (%d) v%s %s", "Related files DB", len(relatedFiles.Related), relatedFiles.Version, color.HiCyanString("%v", argRelatedDB))
 	color.Cyan("%20s: %s", "User-Agent", color.HiCyanString("%v", argUserAgent))
 	color.Cyan("%20s: %s", "Cookie", color.HiCyanString("%v", argCookieString))
 	color.Cyan("%20s: (%d) %s", "Headers", len(argHeaderString), color.HiCyanString("%v", argHeaderString))
@@ -235,3 +502,63 @@ func normalizeArgSlice(arr []string) []string {
 	arr = strings.Split(s, ",")
 	return arr
 }
+
+// Values of flag as given on command line ("--name value" or "--name=value"), every occurrence
+func rawFlagValues(args []string, name string) []string {
+	var values []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--"+name && i+1 < len(args):
+			values = append(values, args[i+1])
+			i++
+		case strings.HasPrefix(args[i], "--"+name+"="):
+			values = append(values, strings.TrimPrefix(args[i], "--"+name+"="))
+		}
+	}
+	return values
+}
+
+// Print every mutation generated for path and what produced it
+// Only options affecting mutations are used
+func explainMutations(fpath string) error {
+	var err error
+	if mutationDateRange, err = parseDateRange(argDateRange); err != nil {
+		return fmt.Errorf("Date range [--date-range]: \n\t%v", err)
+	}
+	if mutationRules, err = loadMutationRules(argMutationRules); err != nil {
+		return fmt.Errorf("Mutation rules [--mutation-rules]: \n\t%v", err)
+	}
+	if templateRules, err = loadTemplateRules(argTemplateRules); err != nil {
+		return fmt.Errorf("Template rules [--template-rules]: \n\t%v", err)
+	}
+	if err := loadRelatedDB(argRelatedDB); err != nil {
+		return fmt.Errorf("Related files database [--related-db]: \n\t%v", err)
+	}
+	if argSmart {
+		if err := loadStats(expandHomePath(argStatsPath)); err != nil {
+			return fmt.Errorf("Stats [--stats]: \n\t%v", err)
+		}
+	}
+
+	// Modification time for dates if path exists locally
+	var modTime time.Time
+	if fi, err := os.Stat(fpath); err == nil {
+		modTime = fi.ModTime()
+	}
+
+	isDir := strings.HasSuffix(fpath, "/")
+	fpath = strings.Trim(filepath.ToSlash(filepath.Clean("/"+fpath)), "/")
+
+	var mutations []mutation
+	if isDir {
+		mutations = explainDirPathMutations(fpath, modTime, argDirMutations)
+	} else {
+		mutations = explainFilePathMutations(fpath, modTime, argBackups)
+	}
+
+	for _, m := range mutations {
+		fmt.Printf("%-50s %s\n", m.fpath, color.CyanString(m.origin))
+	}
+	color.Cyan("\n(%d mutations)", len(mutations)-1)
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Found source file served as is (not executed by server)
+var fileClassRawSource = fileClass{name: "raw-source", severity: severityCritical}
+
+// Server side source files and markers of their raw content
+// Markers of languages using plain words are anchored to line start (HTML text must not match)
+var sourceCodeMarkers = map[string]*regexp.Regexp{
+	".php":   regexp.MustCompile(`<\?php|<\?=`),
+	".phtml": regexp.MustCompile(`<\?php|<\?=`),
+	".inc":   regexp.MustCompile(`<\?php`),
+	".jsp":   regexp.MustCompile(`<%@|<%!|<jsp:`),
+	".asp":   regexp.MustCompile(`<%@|<% `),
+	".aspx":  regexp.MustCompile(`<%@ Page|<%@ Control|<script runat="server"`),
+	".cfm":   regexp.MustCompile(`<cfset|<cfquery|<cfcomponent`),
+	".py":    regexp.MustCompile(`(?m)^\s*(from [\w.]+ import [\w*(]|import [\w.]+(\s+as \w+)?\s*$|def \w+\(.*\)\s*(->.*)?:\s*$)`),
+	".rb":    regexp.MustCompile(`(?m)^\s*(require(_relative)? ['"]|def [a-z_]\w*[?!]?(\(.*\))?\s*$|class [A-Z]\w*(\s*<\s*[A-Z][\w:]*)?\s*$)`),
+	".pl":    regexp.MustCompile(`\A#!/usr/bin/perl|(?m)^use strict;`),
+	".cgi":   regexp.MustCompile(`\A#!/`),
+	".go":    regexp.MustCompile(`(?m)^package \w+\s*$`),
+	".java":  regexp.MustCompile(`(?m)^(package [\w.]+;|import [\w.*]+;)`),
+}
+
+// Content types of raw files (executed scripts are usually text/html or application/json)
+var rawContentTypes = []string{
+	"text/plain", "application/octet-stream", "application/x-httpd-php", "application/x-httpd-php-source",
+	"text/x-php", "application/x-php", "text/x-python", "text/x-script.python", "text/x-ruby", "application/x-perl",
+	"application/x-sh", "text/x-java", "text/x-java-source", "text/x-go",
+}
+
+// Max bytes of body read to look for source markers
+const maxDisclosureReadSize = 64 * 1024
+
+// Check if response of found file is raw source of local file
+// Body is compared to local content, then source markers
+// Raw Content-Type alone is not enough (returned only as reason for weaker note)
+// With HEAD method body is fetched separately
+func detectSourceDisclosure(t *target, srcPath, fullURL, contentType string, body []byte) (bool, string) {
+	if srcPath == "" {
+		return false, ""
+	}
+	rxMarker, isCode := sourceCodeMarkers[strings.ToLower(filepath.Ext(srcPath))]
+	if !isCode {
+		return false, ""
+	}
+
+	if len(body) == 0 && t.method == "HEAD" {
+		if resp, err := fetchURL(t, "GET", fullURL); err == nil {
+			body = readBody(resp, maxDisclosureReadSize)
+		}
+	}
+
+	// Local content found in response
+	if local, err := source.ReadFile(srcPath); err == nil {
+		if sample := sourceSample(local, rxMarker); len(sample) > 0 && bytes.Contains(body, sample) {
+			return true, "matches local " + srcPath
+		}
+	}
+
+	if marker := rxMarker.Find(body); marker != nil {
+		return true, "source marker " + strings.TrimSpace(string(marker))
+	}
+
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	if inSlice(mediaType, rawContentTypes) && len(body) > 0 && !bytes.Contains(bytes.ToLower(body[:minInt(len(body), 512)]), []byte("<html")) {
+		return false, "Content-Type " + mediaType
+	}
+
+	return false, ""
+}
+
+// Delimiters of server side code embedded in HTML ("<?php ... ?>", "<% ... %>")
+var codeOpenTags = [][]byte{[]byte("<?php"), []byte("<?="), []byte("<%")}
+var codeCloseTags = [][]byte{[]byte("?>"), []byte("%>")}
+
+// First line of local source that is code (long enough to be unique)
+// Line must match source marker or be inside code delimiters,
+// plain HTML of template is in output of executed script too
+func sourceSample(data []byte, rxMarker *regexp.Regexp) []byte {
+	inCode := false
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		isCode := inCode || rxMarker.Match(line)
+		for rest := line; ; {
+			tags := codeOpenTags
+			if inCode {
+				tags = codeCloseTags
+			}
+			i, tag := indexAnyTag(rest, tags)
+			if i < 0 {
+				break
+			}
+			isCode, inCode = true, !inCode
+			rest = rest[i+len(tag):]
+		}
+		if isCode && len(line) >= 16 {
+			return line
+		}
+	}
+	return nil
+}
+
+// First of given tags found in s
+func indexAnyTag(s []byte, tags [][]byte) (int, []byte) {
+	first, found := -1, []byte(nil)
+	for _, tag := range tags {
+		if i := bytes.Index(s, tag); i >= 0 && (first < 0 || i < first) {
+			first, found = i, tag
+		}
+	}
+	return first, found
+}
@@ -1,56 +1,249 @@
 package main
 
 import (
+	"fmt"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 )
 
-// Filenames that points there could be more similar files
-var fnamesSimilar = map[string][]string{
-	"composer.json": []string{"composer.lock", "composer.phar"},
-	".htaccess":     []string{".htpasswd"},
-	"Dockerfile": []string{
-		"Dockerfile.production",
-		"Dockerfile.prod",
-		"Dockerfile.dev",
-		"Dockerfile.local",
-		"Dockerfile.loc",
-		"docker-compose.yml",
-		".env",
-	},
+// Generated path with origin of it (pattern, rule, related files...)
+// Origin is shown by `mutations --explain`
+type mutation struct {
+	fpath  string
+	origin string
+}
+
+// Drop repeated paths (same path can be produced by different origins)
+// First origin is kept
+func uniqueMutations(mutations []mutation) []mutation {
+	var unique []mutation
+	seen := map[string]bool{}
+	for _, m := range mutations {
+		if !seen[m.fpath] {
+			seen[m.fpath] = true
+			unique = append(unique, m)
+		}
+	}
+	return unique
 }
 
 // Generate list of file mutations
 // given argument can be single filename [file.txt]
 // or path [path/to/file.txt]
-func filePathMutations(fpath string, patterns []string) []string {
+// modTime is used for date placeholders (zero if unknown)
+// With `--smart` mutations are ordered by hit rate
+func explainFilePathMutations(fpath string, modTime time.Time, patterns []string) []mutation {
 	fname := filepath.Base(fpath)
 	basedir := filepath.Dir(fpath)
 
-	var mutations []string
-	mutations = append(mutations, fpath) // keep original
+	var mutations []mutation
+	mutations = append(mutations, mutation{fpath, "original"}) // keep original
 
 	// Append file names that are similar or related to this
-	if similars, haveSimilar := fnamesSimilar[fname]; haveSimilar {
-		for _, sim := range similars {
-			sim = filepath.Join(basedir, sim)
-			mutations = append(mutations, sim)
-		}
+	for _, rel := range relatedFilesFor(fpath, false) {
+		mutations = append(mutations, mutation{rel, "related files database"})
 	}
 
 	// go and mutate!
+	mutations = append(mutations, fileNameMutations(basedir, fname, modTime, patterns)...)
+	mutations = append(mutations, fileNameRuleMutations(basedir, fname, modTime, mutationRules)...)
+
+	// Real files derived from templates (".env.example" -> ".env")
+	// with mutations of real file too
+	for _, realName := range templateRealNames(fname) {
+		origin := "template of " + realName
+		mutations = append(mutations, mutation{filepath.Join(basedir, realName), origin})
+		for _, m := range fileNameMutations(basedir, realName, modTime, patterns) {
+			mutations = append(mutations, mutation{m.fpath, origin + ", " + m.origin})
+		}
+		for _, m := range fileNameRuleMutations(basedir, realName, modTime, mutationRules) {
+			mutations = append(mutations, mutation{m.fpath, origin + ", " + m.origin})
+		}
+	}
+
+	// color.Red("MUT: %v", mutations)
+	return smartMutations(uniqueMutations(mutations))
+}
+
+// Generate list of directory mutations
+// Directory patterns can point to siblings ("{name}.zip") or inside directory ("{name}/dump.sql")
+// Related files inside directory (e.g. ".idea/workspace.xml") are added too
+func explainDirPathMutations(fpath string, modTime time.Time, patterns []string) []mutation {
+	var mutations []mutation
+	mutations = append(mutations, mutation{fpath, "original"}) // keep original
+	mutations = append(mutations, fileNameMutations(filepath.Dir(fpath), filepath.Base(fpath), modTime, patterns)...)
+	for _, rel := range relatedFilesFor(fpath, true) {
+		mutations = append(mutations, mutation{rel, "related files database (directory)"})
+	}
+	return smartMutations(uniqueMutations(mutations))
+}
+
+// Apply patterns to filename
+// Pattern without placeholders is suffix of filename
+// "*" or "{name}" is full filename (file.tar.gz)
+// "{stem}" is filename without last extension (file.tar)
+// "{ext}" is last extension (gz). Dot is added unless pattern has dot before it
+// "{dir}" is parent directory name
+// "{dot}" is "." unless filename already starts with dot (vim swap ".env.swp", not "..env.swp")
+// "{date}" or "{date:20060102}" is date in Go layout (file modification time or `--date-range`)
+func fileNameMutations(basedir, fname string, modTime time.Time, patterns []string) []mutation {
+	ext := filepath.Ext(fname)
+	stem := strings.TrimSuffix(fname, ext)
+	if stem == "" {
+		stem, ext = fname, "" // dotfiles (".env") has no extension
+	}
+
+	dir := filepath.Base(basedir)
+	if dir == "." || dir == "/" {
+		dir = ""
+	}
+
+	var mutations []mutation
+	seen := map[string]bool{filepath.Join(basedir, fname): true}
 	for _, pattern := range patterns {
-		smut := fname + pattern // as suffix
+		smuts := []string{fname + pattern} // as suffix
 
-		// replace asterisk with fname
-		if strings.Contains(pattern, "*") {
-			smut = strings.Replace(pattern, "*", fname, 1)
+		// replace placeholders with name parts
+		if strings.Contains(pattern, "*") || strings.Contains(pattern, "{") {
+			smuts = expandMutationPattern(pattern, fname, stem, ext, dir, modTime)
 		}
 
-		smut = filepath.Join(filepath.Dir(fpath), smut)
-		mutations = append(mutations, smut)
+		for _, smut := range smuts {
+			smut = filepath.Join(basedir, smut)
+			if !seen[smut] {
+				seen[smut] = true
+				mutations = append(mutations, mutation{smut, fmt.Sprintf("pattern [%s]", pattern)})
+			}
+		}
 	}
+	return mutations
+}
 
-	// color.Red("MUT: %v", mutations)
+// Apply `--mutation-rules` to filename
+func fileNameRuleMutations(basedir, fname string, modTime time.Time, rules []mutationRule) []mutation {
+	var mutations []mutation
+	seen := map[string]bool{filepath.Join(basedir, fname): true}
+	for _, rule := range rules {
+		for _, name := range rule.apply(fname, modTime) {
+			smut := filepath.Join(basedir, name)
+			if !seen[smut] {
+				seen[smut] = true
+				mutations = append(mutations, mutation{smut, fmt.Sprintf("rule [%s]", rule.rule)})
+			}
+		}
+	}
 	return mutations
 }
+
+var rxMutationToken = regexp.MustCompile(`\{(name|stem|ext|dir|dot|date)(?::([^}]+))?\}`)
+
+// Replace placeholders in pattern. Date placeholder gives one result per date
+// Pattern is skipped (nil result) if placeholder value is not available
+func expandMutationPattern(pattern, fname, stem, ext, dir string, modTime time.Time) []string {
+	pattern = strings.Replace(pattern, "*", "{name}", -1)
+
+	dates := []time.Time{{}}
+	if strings.Contains(pattern, "{date") {
+		if dates = mutationDates(modTime); len(dates) == 0 {
+			return nil
+		}
+	}
+
+	var results []string
+	for _, date := range dates {
+		skip := false
+		result := rxMutationToken.ReplaceAllStringFunc(pattern, func(token string) string {
+			m := rxMutationToken.FindStringSubmatch(token)
+			switch m[1] {
+			case "name":
+				return fname
+			case "stem":
+				return stem
+			case "dir":
+				skip = skip || dir == ""
+				return dir
+			case "dot":
+				if strings.HasPrefix(fname, ".") {
+					return ""
+				}
+				return "."
+			case "date":
+				layout := m[2]
+				if layout == "" {
+					layout = "20060102"
+				}
+				return date.Format(layout)
+			}
+			return token
+		})
+
+		// "{ext}" replaced separately to know what is before it
+		for strings.Contains(result, "{ext}") {
+			i := strings.Index(result, "{ext}")
+			sExt := ext
+			if i > 0 && result[i-1] == '.' {
+				sExt = strings.TrimPrefix(ext, ".")
+				if sExt == "" {
+					result = result[:i-1] + result[i:] // no trailing dot
+					i--
+				}
+			}
+			result = result[:i] + sExt + result[i+len("{ext}"):]
+		}
+
+		if !skip && result != "" {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// Max days generated from `--date-range`
+const maxMutationDates = 400
+
+// Dates used in "{date}" placeholder
+// Given date range is used if set, otherwise file modification date
+func mutationDates(modTime time.Time) []time.Time {
+	if len(mutationDateRange) == 2 {
+		var dates []time.Time
+		for d := mutationDateRange[0]; !d.After(mutationDateRange[1]) && len(dates) < maxMutationDates; d = d.AddDate(0, 0, 1) {
+			dates = append(dates, d)
+		}
+		return dates
+	}
+	if modTime.IsZero() {
+		return nil
+	}
+	return []time.Time{modTime}
+}
+
+// Parsed `--date-range` (from, to)
+var mutationDateRange []time.Time
+
+// Parse "2019-09-01..2019-09-30" or single date "2019-09-27"
+func parseDateRange(s string) ([]time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	parts := strings.SplitN(s, "..", 2)
+	if len(parts) == 1 {
+		parts = append(parts, parts[0])
+	}
+
+	var dates []time.Time
+	for _, part := range parts {
+		d, err := time.Parse("2006-01-02", strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid date [%s] (expected: 2006-01-02..2006-01-31)", part)
+		}
+		dates = append(dates, d)
+	}
+	if dates[0].After(dates[1]) {
+		dates[0], dates[1] = dates[1], dates[0]
+	}
+	return dates, nil
+}
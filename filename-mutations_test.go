@@ -0,0 +1,61 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExpandMutationPattern(t *testing.T) {
+	mutationDateRange = nil
+	modTime := time.Date(2019, 10, 5, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		pattern, fname, stem, ext, dir string
+		modTime                        time.Time
+		want                           []string
+	}{
+		{"*.bak", "config.php", "config", ".php", "app", modTime, []string{"config.php.bak"}},
+		{"_*", "config.php", "config", ".php", "app", modTime, []string{"_config.php"}},
+		{"{stem}.old", "config.php", "config", ".php", "app", modTime, []string{"config.old"}},
+		{"{stem}.{ext}.bak", "config.php", "config", ".php", "app", modTime, []string{"config.php.bak"}},
+		{"{stem}{ext}~", "config.php", "config", ".php", "app", modTime, []string{"config.php~"}},
+
+		// empty extension leaves no dangling dot
+		{"{stem}.{ext}.bak", "Makefile", "Makefile", "", "app", modTime, []string{"Makefile.bak"}},
+		{"{stem}{ext}.bak", "Makefile", "Makefile", "", "app", modTime, []string{"Makefile.bak"}},
+		{"{stem}.{ext}", "Makefile", "Makefile", "", "app", modTime, []string{"Makefile"}},
+
+		// vim swap of dotfile has no double dot
+		{"{dot}*.swp", "config.php", "config", ".php", "app", modTime, []string{".config.php.swp"}},
+		{"{dot}*.swp", ".env", ".env", "", "app", modTime, []string{".env.swp"}},
+
+		{"{dir}/{name}", "config.php", "config", ".php", "app", modTime, []string{"app/config.php"}},
+		{"{dir}.zip", "config.php", "config", ".php", "", modTime, nil},
+
+		{"{name}.{date}", "config.php", "config", ".php", "app", modTime, []string{"config.php.20191005"}},
+		{"{date:2006-01}-*", "config.php", "config", ".php", "app", modTime, []string{"2019-10-config.php"}},
+		{"{name}.{date}", "config.php", "config", ".php", "app", time.Time{}, nil},
+	}
+
+	for _, tt := range tests {
+		got := expandMutationPattern(tt.pattern, tt.fname, tt.stem, tt.ext, tt.dir, tt.modTime)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("expandMutationPattern(%q, %q) = %q, want %q", tt.pattern, tt.fname, got, tt.want)
+		}
+	}
+}
+
+func TestExpandMutationPatternDateRange(t *testing.T) {
+	var err error
+	if mutationDateRange, err = parseDateRange("2019-09-30..2019-10-01"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { mutationDateRange = nil }()
+
+	got := expandMutationPattern("{stem}-{date}{ext}", "dump.sql", "dump", ".sql", "", time.Time{})
+	want := []string{"dump-20190930.sql", "dump-20191001.sql"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Template files shipped in repositories (".env.example", "config.php.dist")
+// are copied to real file on deployment. Rules derive real filename from template
+// Format: "pattern => replacement" where every asterisk "*" in pattern
+// captures part of filename and is put in place of asterisk in replacement
+var defaultTemplateRules = []string{
+	"*.example => *",
+	"*.dist => *",
+	"*.sample => *",
+	"*.template => *",
+	"*.tmpl => *",
+	"*.default => *",
+	"*.example.* => *.*", // config.example.php
+	"*.dist.* => *.*",    // phpunit.dist.xml
+	"*.sample.* => *.*",
+	"*-example.* => *.*",
+	"*-sample.* => *.*", // wp-config-sample.php
+	"*-dist.* => *.*",
+	"*_example.* => *.*",
+	"*_sample.* => *.*",
+	"*_template.* => *.*",
+}
+
+type templateRule struct {
+	rule        string
+	rx          *regexp.Regexp
+	replacement string
+}
+
+// Parsed rules used by `filePathMutations`
+var templateRules []templateRule
+
+// Parse rule lines. Empty lines and comments "#" are ignored
+func parseTemplateRules(lines []string) ([]templateRule, error) {
+	var rules []templateRule
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=>", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid template rule [%s] (expected: pattern => replacement)", line)
+		}
+		pattern := strings.TrimSpace(parts[0])
+		replacement := strings.TrimSpace(parts[1])
+		if strings.Count(replacement, "*") > strings.Count(pattern, "*") {
+			return nil, fmt.Errorf("invalid template rule [%s] (more asterisks in replacement than in pattern)", line)
+		}
+
+		// each asterisk is non-empty capture group
+		sRx := "^" + strings.Replace(regexp.QuoteMeta(pattern), `\*`, "(.+?)", -1) + "$"
+		rules = append(rules, templateRule{
+			rule:        line,
+			rx:          regexp.MustCompile(sRx),
+			replacement: replacement,
+		})
+	}
+	return rules, nil
+}
+
+// Load rules from file (added to default rules)
+func loadTemplateRules(fpath string) ([]templateRule, error) {
+	lines := defaultTemplateRules
+	if fpath != "" {
+		f, err := os.Open(fpath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return parseTemplateRules(lines)
+}
+
+// Real filenames derived from template filename
+func templateRealNames(fname string) []string {
+	var names []string
+	for _, rule := range templateRules {
+		m := rule.rx.FindStringSubmatch(fname)
+		if m == nil {
+			continue
+		}
+
+		name := rule.replacement
+		for _, part := range m[1:] {
+			name = strings.Replace(name, "*", part, 1)
+		}
+		if name != "" && name != fname && !inSlice(name, names) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
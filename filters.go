@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Response values used by skip/match filters
+type responseInfo struct {
+	code     int
+	size     int64
+	body     []byte
+	header   http.Header
+	duration time.Duration
+}
+
+// One `--skip-*` or `--match-*` flag. Values of flag are OR'ed
+type responseFilter struct {
+	name  string // flag name for errors and summary
+	match func(r *responseInfo) bool
+}
+
+// Filters combined by `--skip-mode` and `--match-mode`
+var skipFilters []responseFilter
+var matchFilters []responseFilter
+
+// Codes skipped if neither `--skip-code` nor `--match-code` given
+// Checked before other skip filters and not combined with them
+var defaultSkipCodes = []string{"404"}
+var defaultSkipFilters []responseFilter
+
+// Response is shown if not skipped and matched (when match filters given)
+func passFilters(r *responseInfo) bool {
+	if len(defaultSkipFilters) > 0 && combineFilters(defaultSkipFilters, "or", r) {
+		return false
+	}
+	if len(skipFilters) > 0 && combineFilters(skipFilters, argSkipMode, r) {
+		return false
+	}
+	if len(matchFilters) > 0 && !combineFilters(matchFilters, argMatchMode, r) {
+		return false
+	}
+	return true
+}
+
+func combineFilters(filters []responseFilter, mode string, r *responseInfo) bool {
+	for _, f := range filters {
+		matched := f.match(r)
+		if mode == "and" && !matched {
+			return false
+		}
+		if mode != "and" && matched {
+			return true
+		}
+	}
+	return mode == "and"
+}
+
+// Filters need response body (method HEAD can't be used)
+func filtersNeedBody() bool {
+	for _, f := range append(append([]responseFilter{}, skipFilters...), matchFilters...) {
+		if inSlice(strings.TrimPrefix(strings.TrimPrefix(f.name, "skip-"), "match-"), []string{"size", "content", "words", "lines"}) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter of flag is given
+func hasFilter(filters []responseFilter, name string) bool {
+	for _, f := range filters {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// "skip-code, skip-size" for summary
+func filterNames(filters []responseFilter) string {
+	var names []string
+	for _, f := range filters {
+		names = append(names, f.name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Build filters of one kind ("skip" or "match") from flag values
+func buildFilters(kind string, codes, sizes []string, content string, headers, words, lines, times []string) ([]responseFilter, error) {
+	var filters []responseFilter
+
+	numeric := []struct {
+		name   string
+		values []string
+		value  func(r *responseInfo) int64
+	}{
+		{"code", codes, func(r *responseInfo) int64 { return int64(r.code) }},
+		{"size", sizes, func(r *responseInfo) int64 { return r.size }},
+		{"words", words, func(r *responseInfo) int64 { return int64(len(bytes.Fields(r.body))) }},
+		{"lines", lines, func(r *responseInfo) int64 { return int64(countLines(r.body)) }},
+		{"time", times, func(r *responseInfo) int64 { return int64(r.duration / time.Millisecond) }},
+	}
+	for _, n := range numeric {
+		name := kind + "-" + n.name
+		var conds []func(int64) bool
+		for _, v := range n.values {
+			if v = strings.TrimSpace(v); v == "" {
+				continue
+			}
+			cond, err := parseNumberCondition(v, n.name == "code")
+			if err != nil {
+				return nil, fmt.Errorf("[--%s]: \n\t%v", name, err)
+			}
+			conds = append(conds, cond)
+		}
+		if len(conds) == 0 {
+			continue
+		}
+		value := n.value
+		filters = append(filters, responseFilter{name, func(r *responseInfo) bool {
+			v := value(r)
+			for _, cond := range conds {
+				if cond(v) {
+					return true
+				}
+			}
+			return false
+		}})
+	}
+
+	if content != "" {
+		rx, err := regexp.Compile(content)
+		if err != nil {
+			return nil, fmt.Errorf("[--%s-content]: \n\t%v", kind, err)
+		}
+		filters = append(filters, responseFilter{kind + "-content", func(r *responseInfo) bool {
+			return rx.Match(r.body)
+		}})
+	}
+
+	var rxHeaders []*regexp.Regexp
+	for _, h := range headers {
+		if h = strings.TrimSpace(h); h == "" {
+			continue
+		}
+		rx, err := regexp.Compile("(?im)" + h)
+		if err != nil {
+			return nil, fmt.Errorf("[--%s-header]: \n\t%v", kind, err)
+		}
+		rxHeaders = append(rxHeaders, rx)
+	}
+	if len(rxHeaders) > 0 {
+		filters = append(filters, responseFilter{kind + "-header", func(r *responseInfo) bool {
+			s := headerString(r.header)
+			for _, rx := range rxHeaders {
+				if rx.MatchString(s) {
+					return true
+				}
+			}
+			return false
+		}})
+	}
+
+	return filters, nil
+}
+
+var rxNumberCondition = regexp.MustCompile(`^(>=|<=|!=|>|<|=)?\s*(\d+)$`)
+var rxNumberRange = regexp.MustCompile(`^(\d+)\s*-\s*(\d+)$`)
+
+// "404", "4xx" (codes only), "300-399", ">1000", "<=10", "!=0"
+func parseNumberCondition(s string, isCode bool) (func(int64) bool, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	if isCode && len(s) == 3 && strings.HasSuffix(s, "xx") && s[0] >= '1' && s[0] <= '5' {
+		from := int64(s[0]-'0') * 100
+		return func(v int64) bool { return v >= from && v < from+100 }, nil
+	}
+
+	if m := rxNumberRange.FindStringSubmatch(s); m != nil {
+		from, _ := strconv.ParseInt(m[1], 10, 64)
+		to, _ := strconv.ParseInt(m[2], 10, 64)
+		if from > to {
+			from, to = to, from
+		}
+		return func(v int64) bool { return v >= from && v <= to }, nil
+	}
+
+	m := rxNumberCondition.FindStringSubmatch(s)
+	if m == nil {
+		example := "100, >100, <=100, 100-200"
+		if isCode {
+			example = "404, 4xx, 300-399, >=500"
+		}
+		return nil, fmt.Errorf("invalid value [%s] (expected: %s)", s, example)
+	}
+	n, _ := strconv.ParseInt(m[2], 10, 64)
+	op := m[1]
+	if op == "" {
+		op = "="
+	}
+	return func(v int64) bool { return compareInt(v, op, n) }, nil
+}
+
+// Lines of body (last line without newline counts too)
+func countLines(body []byte) int {
+	if len(body) == 0 {
+		return 0
+	}
+	n := bytes.Count(body, []byte("\n"))
+	if body[len(body)-1] != '\n' {
+		n++
+	}
+	return n
+}
+
+// "Key: value" lines of headers (sorted by key) for regex filters
+func headerString(header http.Header) string {
+	var keys []string
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var lines []string
+	for _, k := range keys {
+		for _, v := range header[k] {
+			lines = append(lines, k+": "+v)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNumberCondition(t *testing.T) {
+	tests := []struct {
+		cond   string
+		isCode bool
+		match  []int64
+		skip   []int64
+	}{
+		{"404", true, []int64{404}, []int64{403, 400, 4040}},
+		{"4xx", true, []int64{400, 403, 499}, []int64{399, 500, 200}},
+		{"5XX", true, []int64{500, 503}, []int64{499, 600}},
+		{"300-399", true, []int64{300, 302, 399}, []int64{299, 400}},
+		{"399 - 300", true, []int64{300, 399}, []int64{400}},
+		{">=500", true, []int64{500, 599}, []int64{499}},
+		{"!=200", true, []int64{404, 301}, []int64{200}},
+
+		{"0", false, []int64{0}, []int64{1}},
+		{">1000", false, []int64{1001}, []int64{1000, 0}},
+		{"<= 10", false, []int64{0, 10}, []int64{11}},
+		{"<10", false, []int64{9}, []int64{10}},
+		{"=42", false, []int64{42}, []int64{41}},
+		{" 100-200 ", false, []int64{100, 150, 200}, []int64{99, 201}},
+	}
+
+	for _, tt := range tests {
+		cond, err := parseNumberCondition(tt.cond, tt.isCode)
+		if err != nil {
+			t.Errorf("parseNumberCondition(%q) error: %v", tt.cond, err)
+			continue
+		}
+		for _, v := range tt.match {
+			if !cond(v) {
+				t.Errorf("condition %q should match %d", tt.cond, v)
+			}
+		}
+		for _, v := range tt.skip {
+			if cond(v) {
+				t.Errorf("condition %q should not match %d", tt.cond, v)
+			}
+		}
+	}
+}
+
+func TestParseNumberConditionErrors(t *testing.T) {
+	tests := []struct {
+		cond   string
+		isCode bool
+	}{
+		{"4xx", false}, // code classes only for codes
+		{"6xx", true},
+		{"4x", true},
+		{"abc", false},
+		{">>1", false},
+		{"-5", false},
+		{"1.5", false},
+		{"10-", false},
+	}
+	for _, tt := range tests {
+		if _, err := parseNumberCondition(tt.cond, tt.isCode); err == nil {
+			t.Errorf("parseNumberCondition(%q, %v) expected error", tt.cond, tt.isCode)
+		}
+	}
+}
+
+func TestDefaultSkipCodeWithAndMode(t *testing.T) {
+	defer func(mode string) {
+		argSkipMode, skipFilters, defaultSkipFilters = mode, nil, nil
+	}(argSkipMode)
+
+	var err error
+	if skipFilters, err = buildFilters("skip", nil, []string{"0"}, "", nil, []string{"1"}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if defaultSkipFilters, err = buildFilters("skip", defaultSkipCodes, nil, "", nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	argSkipMode = "and"
+
+	if passFilters(&responseInfo{code: 404, size: 120, body: []byte("not found here")}) {
+		t.Errorf("404 must be skipped regardless of skip mode")
+	}
+	if !passFilters(&responseInfo{code: 200, size: 120, body: []byte("some content")}) {
+		t.Errorf("200 not matched by all skip filters must pass")
+	}
+}
+
+func TestRawFlagValues(t *testing.T) {
+	args := []string{"-s", "./app", "--skip-header", `X-Id: \d{1,3}`, "--match-header=Server: a,b", "--skip-header", "Via"}
+	if got, want := rawFlagValues(args, "skip-header"), []string{`X-Id: \d{1,3}`, "Via"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("skip-header = %q, want %q", got, want)
+	}
+	if got, want := rawFlagValues(args, "match-header"), []string{"Server: a,b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("match-header = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Exit codes for CI pipelines
+const exitClean = 0    // no findings matched `--fail-on` rules
+const exitFindings = 1 // new findings matched `--fail-on` rules
+const exitErrors = 2   // invalid arguments, setup errors or too many failed requests with `--fail-on`
+
+// Rule for `--fail-on`. All conditions must match ("severity>=high and status=200")
+type failRule struct {
+	rule  string
+	conds []failCondition
+}
+
+type failCondition struct {
+	field string // severity, status, class, size
+	op    string
+	value string
+}
+
+var rxFailAnd = regexp.MustCompile(`(?i)\s+and\s+|&&`)
+var rxFailCondition = regexp.MustCompile(`^\s*(severity|status|class|size)\s*(>=|<=|!=|=|>|<)\s*(\S+)\s*$`)
+
+// Parsed `--fail-on` rules (any matching rule fails the scan)
+var failRules []failRule
+
+// Parse rules like "severity>=high", "status=200", "status=2xx and class=credentials"
+func parseFailRules(args []string) ([]failRule, error) {
+	var rules []failRule
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		if arg == "" {
+			continue
+		}
+
+		rule := failRule{rule: arg}
+		for _, part := range rxFailAnd.Split(arg, -1) {
+			m := rxFailCondition.FindStringSubmatch(strings.ToLower(part))
+			if m == nil {
+				return nil, fmt.Errorf("invalid rule [%s] (expected: severity>=high, status=200, class=credentials, size>0)", arg)
+			}
+			cond := failCondition{m[1], m[2], m[3]}
+			if cond.field == "severity" {
+				if _, err := parseSeverity(cond.value); err != nil {
+					return nil, err
+				}
+			}
+			rule.conds = append(rule.conds, cond)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// All conditions of rule match result
+func (rule failRule) match(result *scanResult) bool {
+	for _, cond := range rule.conds {
+		if !cond.match(result) {
+			return false
+		}
+	}
+	return true
+}
+
+func (cond failCondition) match(result *scanResult) bool {
+	switch cond.field {
+	case "severity":
+		severity, _ := parseSeverity(cond.value)
+		return compareInt(int64(result.Severity), cond.op, int64(severity))
+	case "size":
+		n, err := strconv.ParseInt(cond.value, 10, 64)
+		return err == nil && compareInt(result.Size, cond.op, n)
+	case "class":
+		return compareString(result.Class, cond.op, cond.value)
+	case "status":
+		// "2xx" matches whole class of codes
+		if len(cond.value) == 3 && strings.HasSuffix(cond.value, "xx") {
+			return compareString(strconv.Itoa(result.Code)[:1], cond.op, cond.value[:1])
+		}
+		n, err := strconv.ParseInt(cond.value, 10, 64)
+		return err == nil && compareInt(int64(result.Code), cond.op, n)
+	}
+	return false
+}
+
+func compareInt(a int64, op string, b int64) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	}
+	return false
+}
+
+func compareString(a, op, b string) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	}
+	return false
+}
+
+// Accepted findings from `--baseline` file (see `baselineKey`)
+var baseline = map[string]bool{}
+
+// Load baseline file. Missing file is empty baseline (first run with `--baseline-update`)
+// One finding per line, comments "#" ignored
+func loadBaseline(fpath string) (map[string]bool, error) {
+	paths := map[string]bool{}
+	if fpath == "" {
+		return paths, nil
+	}
+
+	f, err := os.Open(fpath)
+	if os.IsNotExist(err) && argBaselineUpdate {
+		return paths, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths[strings.Join(strings.Fields(line), " ")] = true
+	}
+	return paths, scanner.Err()
+}
+
+// Findings of this scan (results not skipped)
+var findings []*scanResult
+
+// Count of failed requests
+var scanErrors = 0
+
+// Collect checked result for gating
+func gateResult(t *target, result *scanResult) {
+	scanMutex.Lock()
+	defer scanMutex.Unlock()
+	if result.Err != nil {
+		scanErrors++
+		t.errors++
+		return
+	}
+	if !result.Skipped {
+		findings = append(findings, result)
+		t.hits++
+	}
+}
+
+// Remove findings of target redirected to soft-404 location (see `isSoftRedirect`)
+func dropRedirectFindings(t *target, key string) int {
+	scanMutex.Lock()
+	defer scanMutex.Unlock()
+	dropped := 0
+	var kept []*scanResult
+	for _, result := range findings {
+		if result.URL == t.endpoint+escapeURLPath(result.Path) && len(result.Redirects) > 0 {
+			if rkey, _ := redirectKey(result.Redirects[len(result.Redirects)-1]); rkey == key {
+				dropped++
+				t.hits--
+				if result.Host != "" {
+					t.vhostHits[result.Host]--
+				}
+				continue
+			}
+		}
+		kept = append(kept, result)
+	}
+	findings = kept
+	return dropped
+}
+
+// Print findings matched `--fail-on` rules, update baseline and return exit code
+func gateExitCode() int {
+	var failed []string
+	suppressed := 0
+	for _, result := range findings {
+		for _, rule := range failRules {
+			if !rule.match(result) {
+				continue
+			}
+			if baseline[baselineKey(result)] {
+				suppressed++
+			} else {
+				failed = append(failed, fmt.Sprintf("%s (%s)", result.URL, rule.rule))
+			}
+			break
+		}
+	}
+
+	if argBaselineUpdate && argBaselinePath != "" {
+		if err := writeBaseline(argBaselinePath); err != nil {
+			color.Red("ERR: Baseline [--baseline]: %v", err)
+			return exitErrors
+		}
+		color.Cyan("-- Baseline updated with %d findings [%s] --", len(findings), argBaselinePath)
+		return exitClean
+	}
+
+	if len(failRules) > 0 {
+		color.Cyan("-- Fail rules: %d new findings, %d suppressed by baseline --", len(failed), suppressed)
+	}
+	for _, s := range failed {
+		color.Red("FAIL: %s", s)
+	}
+
+	if scanErrors > 0 {
+		color.Red("-- %d requests failed --", scanErrors)
+	}
+
+	// Failed requests matter only for gated scans (findings could be missed)
+	// A few timeouts in a long scan are tolerated by `--max-errors`
+	switch {
+	case len(failed) > 0:
+		return exitFindings
+	case len(failRules) > 0 && scanErrors > argMaxErrors:
+		color.Red("-- More than %d requests failed [--max-errors] --", argMaxErrors)
+		return exitErrors
+	}
+	return exitClean
+}
+
+// Accept all current findings
+func writeBaseline(fpath string) error {
+	var lines []string
+	lines = append(lines, "# findthese baseline: accepted findings (URL and Host header in vhost mode)")
+	seen := map[string]bool{}
+	for _, result := range findings {
+		key := baselineKey(result)
+		if !seen[key] {
+			seen[key] = true
+			lines = append(lines, key)
+		}
+	}
+	return ioutil.WriteFile(fpath, []byte(strings.Join(lines, "\n")+"\n"), 0664)
+}
+
+// Finding is accepted per target and vhost, not for every host with the same path
+// Escaped URL and Host header in vhost mode ("https://10.0.0.5/.env staging.corp")
+func baselineKey(result *scanResult) string {
+	if result.Host != "" {
+		return result.URL + " " + result.Host
+	}
+	return result.URL
+}
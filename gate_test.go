@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestParseFailRules(t *testing.T) {
+	env := &scanResult{Code: 200, Size: 120, Class: "credentials", Severity: severityCritical}
+	forbidden := &scanResult{Code: 403, Size: 0, Class: "credentials", Severity: severityInfo}
+	readme := &scanResult{Code: 200, Size: 0, Class: "other", Severity: severityInfo}
+
+	tests := []struct {
+		rule string
+		want []bool // env, forbidden, readme
+	}{
+		{"severity>=high", []bool{true, false, false}},
+		{"severity=info", []bool{false, true, true}},
+		{"status=200", []bool{true, false, true}},
+		{"status!=200", []bool{false, true, false}},
+		{"status=2xx", []bool{true, false, true}},
+		{"status=4XX", []bool{false, true, false}},
+		{"status!=2xx", []bool{false, true, false}},
+		{"status>=400", []bool{false, true, false}},
+		{"class=credentials", []bool{true, true, false}},
+		{"size>0", []bool{true, false, false}},
+
+		// all conditions of rule must match
+		{"status=2xx and class=credentials", []bool{true, false, false}},
+		{"status=200 AND size=0", []bool{false, false, true}},
+		{"class=credentials && status=403", []bool{false, true, false}},
+		{" severity >= medium and status = 2xx ", []bool{true, false, false}},
+	}
+
+	for _, tt := range tests {
+		rules, err := parseFailRules([]string{tt.rule})
+		if err != nil {
+			t.Errorf("parseFailRules(%q) error: %v", tt.rule, err)
+			continue
+		}
+		if len(rules) != 1 {
+			t.Errorf("parseFailRules(%q) = %d rules, want 1", tt.rule, len(rules))
+			continue
+		}
+		for i, result := range []*scanResult{env, forbidden, readme} {
+			if got := rules[0].match(result); got != tt.want[i] {
+				t.Errorf("rule %q on %d/%s = %v, want %v", tt.rule, result.Code, result.Class, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestParseFailRulesErrors(t *testing.T) {
+	for _, rule := range []string{
+		"severity>=extreme",
+		"status",
+		"code=200",
+		"status=200 and",
+		"status=200 or size>0",
+	} {
+		if _, err := parseFailRules([]string{rule}); err == nil {
+			t.Errorf("parseFailRules(%q) expected error", rule)
+		}
+	}
+
+	// empty values are ignored
+	if rules, err := parseFailRules([]string{"", "  "}); err != nil || len(rules) != 0 {
+		t.Errorf("parseFailRules(empty) = %v, %v", rules, err)
+	}
+}
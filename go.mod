@@ -1,6 +1,6 @@
 module findthese
 
-go 1.13
+go 1.16
 
 require (
 	github.com/fatih/color v1.7.0
@@ -8,4 +8,5 @@ require (
 	github.com/mattn/go-colorable v0.1.2 // indirect
 	github.com/mattn/go-isatty v0.0.9 // indirect
 	golang.org/x/sys v0.0.0-20190927073244-c990c680b611 // indirect
+	gopkg.in/yaml.v2 v2.2.4
 )
@@ -1,5 +1,10 @@
 package main
 
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
 
 // Find in slice
 func inSlice(a string, list []string) bool {
@@ -11,3 +16,32 @@ func inSlice(a string, list []string) bool {
 	}
 	return false
 }
+
+// Escape characters that breaks URL path when used raw
+// "#" would start fragment (emacs "#file#"), "?" query
+// Already escaped sequences (%2e) are kept as is
+func escapeURLPath(upath string) string {
+	upath = strings.Replace(upath, "#", "%23", -1)
+	upath = strings.Replace(upath, "?", "%3F", -1)
+	upath = strings.Replace(upath, " ", "%20", -1)
+	return upath
+}
+
+// "~/file" -> "/home/user/file"
+func expandHomePath(fpath string) string {
+	if !strings.HasPrefix(fpath, "~/") {
+		return fpath
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fpath
+	}
+	return filepath.Join(home, fpath[2:])
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -22,6 +22,7 @@ func LogSetupAndDestruct(fpath string) func() {
 	}
 	log.SetFlags(0)
 	log.SetOutput(io.MultiWriter(os.Stdout, logFile))
+	reportFile = logFile
 
 	return func() {
 		e := logFile.Close()
@@ -1,10 +1,7 @@
 package main
 
 import (
-	"bytes"
-	"crypto/tls"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
@@ -21,7 +18,45 @@ var version = "v0.1"
 
 // flags
 var argSourcePath string
-var argEndpoint string
+var argSourceRef string
+var argImagePath string
+var argWordlist string
+var argPathsList string
+var argWebRootMaps []string
+var argRoutes = true     // assigned default value
+var argReferences = true // assigned default value
+var argTemplateRules string
+var argRelatedDB string
+var argDateRange string
+var argMutationRules string
+var argExplainPath string
+var argCaseVariants = false                  // assigned default value
+var argEncodeVariants = false                // assigned default value
+var argStatsPath = "~/.findthese.stats.json" // assigned default value
+var argSmart = false                         // assigned default value
+var argPriority = true                       // assigned default value
+var argMinSeverity = "info"                  // assigned default value
+var argFailOn []string
+var argBaselinePath string
+var argBaselineUpdate = false   // assigned default value
+var argMaxErrors = 10           // assigned default value
+var argHeadFallback = true      // assigned default value
+var argCrossCheck = false       // assigned default value
+var argMaxBody = 1048576        // assigned default value
+var argFollowRedirects = "none" // assigned default value
+var argMaxRedirects = 10        // assigned default value
+var argTLSVerify = false        // assigned default value
+var argCACert string
+var argClientCert string
+var argClientKey string
+var argSNI string
+var argTLSMinVersion string
+var argResolve []string
+var argVhosts []string
+var argVhostsFile string
+var argEndpoints []string
+var argTargetsFile string
+var argConcurrency = 4                                                              // assigned default value
 var argMethod = "HEAD"                                                              // assigned default value
 var argUserAgent = "random"                                                         // assigned default value
 var argReportPath = "./findthese.report"                                            // assigned default value
@@ -30,64 +65,149 @@ var argTimeout = 10
 var argDepth = 0                                                                    // assigned default value
 var argSkip = []string{"jquery", "css", "img", "images", "i18n", "po"}              // assigned default value
 var argSkipExts = []string{".png", ".jpeg", "jpg", "Gif", ".CSS", ".less", ".sass"} // assigned default value
-var argSkipCodes = []string{"404"}                                                  // assigned default value
+var argSkipCodes = []string{}                                                       // assigned default value
 var argSkipSizes = []string{}                                                       // assigned default value
 var argSkipContent string                                                           // assigned default value
-var argDirOnly = false                                                              // assigned default value
-var argCookieString = ""                                                            // assigned default value
-var argHeaderString = ""                                                            // assigned default value
+var argSkipHeaders []string
+var argSkipWords []string
+var argSkipLines []string
+var argSkipTime []string
+var argSkipMode = "or" // assigned default value
+var argMatchCodes []string
+var argMatchSizes []string
+var argMatchContent string
+var argMatchHeaders []string
+var argMatchWords []string
+var argMatchLines []string
+var argMatchTime []string
+var argMatchMode = "or"  // assigned default value
+var argDirOnly = false   // assigned default value
+var argCookieString = "" // assigned default value
+var argHeaderString = "" // assigned default value
+
+// Source of files to check (directory, archive, git ref)
+var source scanSource
 
 // Parse `argHeaderString` and fills this map
 var mHeaders = map[string]string{}
 
-// asterisk "*" replaced by filename
-// if no asterisk found treat as suffix
-var argBackups = []string{"~", ".swp", ".swo", ".tmp", ".dmp", ".bkp", ".backup", ".bak", ".zip", ".tar", ".old", "_*", "~*"} // assigned default value
+// asterisk "*" or "{name}" replaced by filename, "{stem}" by filename without extension
+// if none of them found treat as suffix
+var argBackups = []string{
+	"~", ".tmp", ".dmp", ".bkp", ".backup", ".bak", ".zip", ".tar", ".old", ".orig", ".save", "_*", "~*",
 
-// Walk mode. Before real check/fetch count ETA
-const walkModeCount = 0
-const walkModeProcess = 1
+	// vim swap files in the same dir (no extra dot for dotfiles)
+	"{dot}*.swp", "{dot}*.swo", "{dot}*.swn",
+
+	// emacs auto-save and lock files, numbered backups (emacs, cp --backup=numbered)
+	"#*#", ".#*", "*.~1~", "*.~2~",
+
+	// macOS AppleDouble
+	"._*",
+
+	// config.bak from config.php
+	"{stem}.bak", "{stem}.old",
+} // assigned default value
+
+// Directory mutations. Same placeholders as for files
+// Archives of directory next to it and common dump names inside it
+var argDirMutations = []string{
+	".zip", ".tar", ".tar.gz", ".tgz", ".tar.bz2", ".rar", ".7z", ".bak", ".old", "~", "_old",
+	"backup/{name}.zip", "backup/{name}.tar.gz", "backup/{name}.tgz",
+	"{name}/{name}.zip", "{name}/{name}.tar.gz", "{name}/{name}.sql",
+	"{name}/backup.zip", "{name}/backup.tar.gz", "{name}/backup.sql",
+	"{name}/site.zip", "{name}/site.tar.gz", "{name}/site.sql",
+	"{name}/dump.sql", "{name}/db.sql", "{name}/database.sql", "{name}/data.sql",
+} // assigned default value
 
-var walkMode = walkModeCount
 var dirItemCount = 0
 var totalScanCount = 0
 
 func main() {
 	parseArgs()
 
-	// TODO: Count items in source path folder and calc ~ETA
-	walkMode = walkModeCount
-	filepath.Walk(argSourcePath, localFileVisit)
+	// Profile for hit-rate stats must be known before walk
+	// because `--smart` drops mutations by stats of this profile
+	if argStatsPath != "" {
+		scanFramework = detectFramework(source)
+	}
+	if argSmart {
+		probeServer(targets[0])
+		scanServer = targets[0].server
+	}
+
+	// Walk local source directory and collect candidates
+	if err := walkSource(source); err != nil {
+		fmt.Printf("ERR: Source (%s): %v\n", source.Type(), err)
+	}
+	if argReferences {
+		queueReferences()
+	}
+	sortScanQueue()
+	totalScanCount = countScanQueue()
 	// durETA := time.Duration(totalScanCount*(argDelay+200)) * time.Millisecond
 	printUsedArgs()
 
 	// Setup logging
-	defer LogSetupAndDestruct(argReportPath)()
+	closeLog := LogSetupAndDestruct(argReportPath)
 
-	// Walk local source directory
-	log.Printf("(START) -- (%d items + %d mutations)", dirItemCount, totalScanCount)
-	walkMode = walkModeProcess
+	// Check collected candidates (highest priority first) on every target
+	// HEAD is replaced with GET for target answering it differently
+	log.Printf("(START) -- (%d items + %d mutations) x %d targets", dirItemCount, totalScanCount/len(targets)-dirItemCount, len(targets))
 	fmt.Println(strings.Repeat("-", 80))
-	if err := filepath.Walk(argSourcePath, localFileVisit); err != nil {
-		fmt.Printf("ERR: Local directory: %v\n", err)
-	}
-	fmt.Println("\n" + strings.Repeat("-", 80))
+	scanTargets()
+	clearLine()
+	fmt.Println(strings.Repeat("-", 80))
+	writeTargetReports()
+	log.Printf("(BYTES) -- %s transferred", formatBytes(bytesTransferred))
 	log.Printf("(END)")
 
+	if err := saveStats(argStatsPath); err != nil {
+		color.Red("ERR: Stats [--stats]: %v", err)
+	}
+
+	// Exit code for CI pipelines
+	exitCode := gateExitCode()
+	closeLog()
+	os.Exit(exitCode)
+}
+
+// Source path to URL path of currently walked source
+var walkURLPath = mapToURLPath
+
+// Walk sources one by one. Wordlist and path list entries are URL paths already
+// so web root mapping is applied only to source tree
+func walkSource(src scanSource) error {
+	if sources, ok := src.(multiSource); ok {
+		for _, s := range sources {
+			if err := walkSource(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	walkURLPath = mapToURLPath
+	if inSlice(src.Type(), []string{"wordlist", "paths"}) {
+		walkURLPath = func(fpath string) (string, bool) { return fpath, true }
+	}
+	return src.Walk(localFileVisit)
 }
 
 // Last line length to know how much to clean
 var lastLineLength int // cleaning current line with previous line length
 
 // callback
+// fpath is relative to source root
 func localFileVisit(fpath string, f os.FileInfo, err error) error {
-	fpath = strings.TrimPrefix(fpath, argSourcePath) // without local directory path
 	depth := strings.Count(fpath, "/") + 1
 
 	if fpath == "" {
 		return nil
 	}
 
+	sourcePaths[fpath] = true
+
 	//  skip file if allowed to scan only directories
 	if argDirOnly && !f.IsDir() {
 		return nil
@@ -103,6 +223,10 @@ func localFileVisit(fpath string, f os.FileInfo, err error) error {
 
 		if inSlice(f.Name(), []string{".", "..", ".hg", ".git"}) {
 			// fmt.Printf("-- SKIP ALWAYS [%s] --", f.Name())
+			// Repository is not walked but its known files are checked (".git/config")
+			if !inSlice(f.Name(), argSkip) {
+				queueRelatedDirFiles(fpath)
+			}
 			return filepath.SkipDir
 		}
 	}
@@ -124,116 +248,238 @@ func localFileVisit(fpath string, f os.FileInfo, err error) error {
 		}
 	}
 
-	// counting mode
-	if walkMode == walkModeCount {
-		dirItemCount++
-		totalScanCount += len(argBackups) - 1
+	// Routes defined in source files are checked as additional candidates
+	// Route files usually are outside web root so check them before mapping
+	if argRoutes && !f.IsDir() {
+		visitRoutes(fpath)
+	}
+
+	// Paths referenced in file content are collected while walking
+	// and queued after whole source is walked
+	if argReferences && !f.IsDir() {
+		collectReferences(fpath)
+	}
+
+	// Source items outside web root are not reachable by URL
+	if _, served := walkURLPath(fpath); !served {
+		if f.IsDir() && !isWebRootAncestor(fpath) {
+			return filepath.SkipDir
+		}
 		return nil
 	}
 
 	// generate mutations fpath list based on given fpath
-	var fpaths []string
-	fpaths = filePathMutations(fpath, argBackups)
+	// file mutations makes no sense for directories so they have own list
+	var mutations []mutation
+	if f.IsDir() {
+		mutations = explainDirPathMutations(fpath, f.ModTime(), argDirMutations)
+	} else {
+		mutations = explainFilePathMutations(fpath, f.ModTime(), argBackups)
+	}
 
-	// Loop throw all fpath versions
-	for _, fpath := range fpaths {
-		fullURL := argEndpoint + fpath
-		// fname := filepath.Base(fpath)
+	// Source paths as they are seen from URL
+	umutations := urlMutations(mutations, walkURLPath)
 
-		// Delay after basic checks and right before call
-		if argDelay > 0 {
-			time.Sleep(time.Duration(argDelay) * time.Millisecond)
-		}
-
-		// Fetch
-		resp, err := fetchURL(argMethod, fullURL)
-		if err != nil {
-			color.Red("ERR: %v", err)
-			fmt.Println()
-			continue
-		}
+	dirItemCount++
+	queueURLMutations(umutations, "", fpath)
 
-		sCode := fmt.Sprintf("%d", resp.StatusCode)
+	return nil
+}
 
-		// try to read real body length if empty
-		var buf []byte
-		buf, _ = ioutil.ReadAll(resp.Body)
-		resp.Body.Close()
+// Queue related files database entries of directory that is not walked
+func queueRelatedDirFiles(fpath string) {
+	if _, served := walkURLPath(fpath); !served {
+		return
+	}
+	var mutations []mutation
+	for _, rel := range relatedFilesFor(fpath, true) {
+		mutations = append(mutations, mutation{rel, "related files database (directory)"})
+	}
+	queueURLMutations(urlMutations(smartMutations(mutations), walkURLPath), "", fpath)
+}
 
-		if resp.ContentLength <= 0 {
-			resp.ContentLength = int64(len(buf))
+// Mutations with source path replaced by URL path
+// Paths not served by web root are dropped
+func urlMutations(mutations []mutation, toURLPath func(string) (string, bool)) []mutation {
+	var umutations []mutation
+	for _, m := range mutations {
+		if upath, served := toURLPath(m.fpath); served {
+			umutations = append(umutations, mutation{upath, m.origin})
 		}
-		sLength := fmt.Sprintf("%d", resp.ContentLength)
+	}
+	return umutations
+}
+
+// Result of one checked URL
+type scanResult struct {
+	Path      string // URL path relative to endpoint
+	URL       string
+	Host      string // Host header in vhost mode
+	Note      string
+	Code      int
+	Size      int64
+	Class     string // sensitive file class from catalog
+	Severity  int
+	Redirects []string // redirect chain (without requested URL)
+	Skipped   bool     // matched "skip" rules or below `--min-severity`
+	Err       error
+}
 
-		// Check for "skip" rules
-		isSkipable := inSlice(sCode, argSkipCodes)
+// Request URL path (relative to target endpoint) and print/log result
+// note is appended to result line (e.g. where candidate came from)
+func checkURL(t *target, c candidate) *scanResult {
+	upath, note, class := c.fpath, c.note, c.class
+	fullURL := t.endpoint + escapeURLPath(upath)
+	result := &scanResult{Path: upath, URL: fullURL, Host: t.requestHost, Note: note, Class: class.name, Severity: class.severity}
+	defer gateResult(t, result)
+	if t.requestHost != "" {
+		note = strings.TrimSpace(note + " [VHOST " + t.requestHost + "]")
+		result.Note = note
+	}
 
-		// by size
-		isSkipable = isSkipable || inSlice(sLength, argSkipSizes)
+	// Delay after basic checks and right before call
+	if t.delay > 0 {
+		time.Sleep(time.Duration(t.delay) * time.Millisecond)
+	}
 
-		// Skip content for specifix methods
-		if !isSkipable && argMethod != "HEAD" {
-			// by content
-			if argSkipContent != "" {
-				isSkipable = bytes.Contains(buf, []byte(argSkipContent))
-			}
-		}
+	// Fetch (error is logged by fetch)
+	started := time.Now()
+	resp, err := fetchURL(t, t.method, fullURL)
+	if err != nil {
+		result.Err = err
+		return result
+	}
 
-		fmt.Printf("\r")
-		fmt.Printf(strings.Repeat(" ", lastLineLength)) // cleaning
-		fmt.Printf("\r")
+	code := statusCode(resp)
+	sCode := fmt.Sprintf("%d", code)
+	result.Code = code
+	if t.server == "" {
+		t.server = serverName(resp.Header.Get("Server"))
+	}
 
-		sMore := "" // add at the end of line
-		switch {
+	// try to read real body length if empty
+	// only requested range is read if body is limited
+	buf := readBody(resp, t.rangeLimit)
 
-		case isSkipable:
-			sLine := fmt.Sprintf("-> %s%s \tCODE:%s ", color.MagentaString(argEndpoint), fpath, sCode)
+	result.Size = responseSize(resp, buf)
+	sLength := fmt.Sprintf("%d", result.Size)
 
-			if argMethod != "HEAD" {
-				sLine += fmt.Sprintf("SIZE:%s ", sLength)
-			}
+	// Class of path matters only if file is served (403, redirect to login is not exposure)
+	if code < 200 || code >= 300 {
+		class = fileClassOther
+		result.Class, result.Severity = class.name, class.severity
+	}
 
-			lastLineLength = len(sLine)
-			fmt.Printf(sLine)
-			continue
+	// Check for "skip" and "match" rules
+	isSkipable := !passFilters(&responseInfo{
+		code:     code,
+		size:     result.Size,
+		body:     buf,
+		header:   resp.Header,
+		duration: time.Since(started),
+	})
+
+	// Redirects to the same page (login, home) from many paths are noise
+	if chain := redirectChain(resp); len(chain) > 0 {
+		result.Redirects = chain
+		note = strings.TrimSpace(note + " " + redirectNote(chain))
+		result.Note = note
+		isSkipable = isSkipable || isSoftRedirect(t, upath, chain[len(chain)-1])
+	}
 
-		case sCode == "200":
-			sCode = color.HiGreenString(sCode)
-			sMore += color.GreenString(fullURL)
+	// Source file served raw instead of executed
+	if !isSkipable && code >= 200 && code < 300 {
+		if raw, reason := detectSourceDisclosure(t, c.srcPath, fullURL, resp.Header.Get("Content-Type"), buf); raw {
+			class = fileClassRawSource
+			result.Class, result.Severity = class.name, class.severity
+			note = strings.TrimSpace(note + " [RAW SOURCE: " + reason + "]")
+			result.Note = note
+		} else if reason != "" {
+			note = strings.TrimSpace(note + " [POSSIBLE RAW SOURCE: " + reason + "]")
+			result.Note = note
+		}
+	}
 
-		case sCode[:1] == "3": // 3xx codes
-			sCode = color.CyanString(sCode)
-			sMore += color.CyanString(fullURL)
+	// by severity of file class
+	isSkipable = isSkipable || class.severity < minSeverity
 
-		case sCode[:1] == "4": // 4xx codes
-			sCode = color.RedString(sCode)
-			sMore += color.RedString(fullURL)
+	result.Skipped = isSkipable
+	if !isSkipable && t.requestHost != "" {
+		t.vhostHits[t.requestHost]++
+	}
 
-		case sCode[:1] == "5": // 5xx codes
-			sCode = color.BlueString(sCode)
-			sMore += color.BlueString(fullURL)
+	// Hit confirmed with other method
+	if !isSkipable && argCrossCheck && code >= 200 && code < 300 {
+		if mismatch := crossCheckMethod(t, fullURL, code); mismatch != "" {
+			note = strings.TrimSpace(note + " " + mismatch)
+			result.Note = note
 		}
+	}
 
-		// fmt.Printf("\r")
+	sMore := "" // add at the end of line
+	switch {
 
-		msg := fmt.Sprintf("%s ", argMethod)
-		msg += fmt.Sprintf("CODE:%-4s ", sCode)
-		if argMethod != "HEAD" {
-			msg += fmt.Sprintf("SIZE:%-10s ", sLength)
+	case isSkipable:
+		sLine := fmt.Sprintf("-> %s%s \tCODE:%s ", color.MagentaString(t.endpoint), upath, sCode)
+
+		if t.method != "HEAD" {
+			sLine += fmt.Sprintf("SIZE:%s ", sLength)
 		}
-		msg += sMore
 
-		// color.Red("%d < %d", len(msg), cleanupLen)
+		printProgress(sLine)
+		return result
+
+	case sCode == "200":
+		sCode = color.HiGreenString(sCode)
+		sMore += severityColor(class.severity, "%s", fullURL)
 
-		log.Println(msg)
+	case sCode[:1] == "3": // 3xx codes
+		sCode = color.CyanString(sCode)
+		sMore += color.CyanString("%s", fullURL)
+
+	case sCode[:1] == "4": // 4xx codes
+		sCode = color.RedString(sCode)
+		sMore += color.RedString("%s", fullURL)
+
+	case sCode[:1] == "5": // 5xx codes
+		sCode = color.BlueString(sCode)
+		sMore += color.BlueString("%s", fullURL)
 	}
 
-	return nil
+	// fmt.Printf("\r")
+
+	msg := fmt.Sprintf("%s ", t.method)
+	msg += fmt.Sprintf("CODE:%-4s ", sCode)
+	if t.method != "HEAD" {
+		msg += fmt.Sprintf("SIZE:%-10s ", sLength)
+	}
+	msg += sMore
+	if class.severity > severityInfo {
+		msg += " " + severityColor(class.severity, "[%s %s]", strings.ToUpper(severityNames[class.severity]), class.name)
+	}
+	if note != "" {
+		msg += " " + color.YellowString(note)
+	}
+
+	// color.Red("%d < %d", len(msg), cleanupLen)
+
+	t.log(msg)
+	return result
 }
 
-// Fetches url content to dataTarget
-func fetchURL(method, URL string) (*http.Response, error) {
-	client := requestClient(URL)
+// Request endpoint root to know server type before scan
+func probeServer(t *target) {
+	resp, err := fetchURL(t, t.method, t.endpoint)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+	t.server = serverName(resp.Header.Get("Server"))
+}
+
+// Fetches url content of target (vhost and range settings of target are used)
+func fetchURL(t *target, method, URL string) (*http.Response, error) {
+	client := requestClient(URL, t.requestHost)
 
 	// Request
 	req, _ := http.NewRequest(method, URL, nil)
@@ -244,16 +490,30 @@ func fetchURL(method, URL string) (*http.Response, error) {
 	// Cookies string
 	req.Header.Set("Cookie", argCookieString)
 
+	// Only beginning of body when HEAD can't be used
+	if method == "GET" && t.rangeLimit > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", t.rangeLimit-1))
+	}
+
 	// Custom headers
 	// Can override previously set headers
+	// Host header is request field (not sent from headers map)
 	for hKey, hVal := range mHeaders {
 		req.Header.Set(hKey, hVal)
+		if strings.EqualFold(hKey, "Host") {
+			req.Host = hVal
+		}
+	}
+
+	// Virtual host mode
+	if t.requestHost != "" {
+		req.Host = t.requestHost
 	}
 
 	// Make request
 	resp, reqErr := client.Do(req)
 	if reqErr != nil {
-		log.Printf("ERROR: [FETCH] %s -- %v", URL, reqErr)
+		t.log(fmt.Sprintf("ERROR: [FETCH] %s -- %v", URL, reqErr))
 		return nil, reqErr
 	}
 
@@ -261,23 +521,27 @@ func fetchURL(method, URL string) (*http.Response, error) {
 }
 
 // Common request http client for data fetch
-func requestClient(URL string) *http.Client {
+// requestHost is Host header of vhost mode (empty - host of URL)
+func requestClient(URL, requestHost string) *http.Client {
 	u, _ := url.Parse(URL)
 
-	tr := &http.Transport{}
+	tr := &http.Transport{
+		DialContext: dialContext, // `--resolve` pinned addresses
+	}
 
 	if u.Scheme == "https" {
-		tr.TLSClientConfig = &tls.Config{
-			InsecureSkipVerify: true,
+		tr.TLSClientConfig = tlsConfig.Clone()
+
+		// SNI of virtual host unless given
+		if requestHost != "" && argSNI == "" {
+			tr.TLSClientConfig.ServerName = strings.Split(requestHost, ":")[0]
 		}
 	}
 
 	client := &http.Client{
-		Transport: tr,
-		Timeout:   time.Duration(argTimeout) * time.Second,
-		// CheckRedirect: func(req *http.Request, via []*http.Request) error {
-		// 	return http.ErrNoLocation
-		// },
+		Transport:     tr,
+		Timeout:       time.Duration(argTimeout) * time.Second,
+		CheckRedirect: checkRedirect,
 	}
 
 	return client
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Body bytes read during scan (all targets)
+var bytesTransferred int64
+
+// Range used after HEAD fallback (enough for source disclosure markers)
+const headFallbackRange = 1024
+
+// Compare HEAD and GET responses for endpoint root and surely missing path
+// HEAD is unreliable if not allowed or gives other status than GET
+// Bytes requested with `Range` header are limited after fallback
+func calibrateMethod(t *target) {
+	if t.method != "HEAD" || !argHeadFallback {
+		return
+	}
+
+	rand.Seed(time.Now().UnixNano())
+	missing := fmt.Sprintf("findthese-%d.txt", rand.Int63())
+
+	for _, upath := range []string{"", missing} {
+		fullURL := t.endpoint + upath
+		headCode, err := fetchStatus(t, "HEAD", fullURL)
+		if err != nil {
+			return // endpoint not reachable - nothing to calibrate
+		}
+		getCode, err := fetchStatus(t, "GET", fullURL)
+		if err != nil {
+			return
+		}
+
+		switch {
+		case headCode == http.StatusMethodNotAllowed || headCode == http.StatusNotImplemented:
+			t.headFallbackReason = fmt.Sprintf("HEAD not allowed (%d)", headCode)
+		case headCode != getCode:
+			t.headFallbackReason = fmt.Sprintf("HEAD %d != GET %d for /%s", headCode, getCode, upath)
+		}
+		if t.headFallbackReason != "" {
+			t.method = "GET"
+			if t.rangeLimit == 0 || t.rangeLimit > headFallbackRange {
+				t.rangeLimit = headFallbackRange
+			}
+			t.log(color.YellowString("-- %s: %s. Using GET with Range bytes=0-%d --", t.endpoint, t.headFallbackReason, t.rangeLimit-1))
+			return
+		}
+	}
+}
+
+// Status code of URL (body is dropped)
+func fetchStatus(t *target, method, fullURL string) (int, error) {
+	resp, err := fetchURL(t, method, fullURL)
+	if err != nil {
+		return 0, err
+	}
+	readBody(resp, headFallbackRange)
+	return statusCode(resp), nil
+}
+
+// Read body up to limit (0 - no limit) and close it
+// Rest of body is not downloaded (connection is closed)
+func readBody(resp *http.Response, limit int64) []byte {
+	var r io.Reader = resp.Body
+	if limit > 0 {
+		r = io.LimitReader(resp.Body, limit)
+	}
+	body, _ := ioutil.ReadAll(r)
+	resp.Body.Close()
+	atomic.AddInt64(&bytesTransferred, int64(len(body)))
+	return body
+}
+
+// 1536 -> "1.5 KB"
+func formatBytes(n int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	f := float64(n)
+	i := 0
+	for f >= 1024 && i < len(units)-1 {
+		f /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f %s", f, units[i])
+}
+
+// Partial response to our own Range request is the same as full one
+func statusCode(resp *http.Response) int {
+	if resp.StatusCode == http.StatusPartialContent && resp.Request != nil && resp.Request.Header.Get("Range") != "" {
+		return http.StatusOK
+	}
+	return resp.StatusCode
+}
+
+// Total size of response. Content-Range "bytes 0-1023/52345" holds it for partial response
+func responseSize(resp *http.Response, body []byte) int64 {
+	if cr := resp.Header.Get("Content-Range"); cr != "" {
+		if i := strings.LastIndex(cr, "/"); i >= 0 {
+			if total, err := strconv.ParseInt(cr[i+1:], 10, 64); err == nil {
+				return total
+			}
+		}
+	}
+	if resp.ContentLength > 0 && resp.StatusCode != http.StatusPartialContent {
+		return resp.ContentLength
+	}
+	return int64(len(body))
+}
+
+// Request hit with other method and report if status differs
+// Returns note for result line (empty if the same)
+func crossCheckMethod(t *target, fullURL string, code int) string {
+	other := "GET"
+	if t.method != "HEAD" {
+		other = "HEAD"
+	}
+	otherCode, err := fetchStatus(t, other, fullURL)
+	if err != nil || otherCode == code {
+		return ""
+	}
+	t.methodMismatches++
+	return fmt.Sprintf("[METHOD MISMATCH %s:%d %s:%d]", t.method, code, other, otherCode)
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Mutation rules similar to hashcat rule language applied to filename
+// (c) https://hashcat.net/wiki/doku.php?id=rule_based_attack
+//
+// One rule per line, functions are applied one after another (spaces between functions ignored)
+//
+//	:     do nothing (keep filename)
+//	l u   lowercase / uppercase all
+//	c C   capitalize / lowercase first and uppercase rest
+//	t TN  toggle case of all / at position N
+//	r d f reverse / duplicate / reflect (filename + reversed filename)
+//	$X ^X append / prepend character X
+//	[ ]   delete first / last character
+//	DN    delete character at position N
+//	iNX   insert character X at position N
+//	oNX   overwrite character at position N with X
+//	sXY   replace all X with Y
+//	@X    purge all X
+//
+// Not in hashcat:
+//
+//	A"str"    append string
+//	P"str"    prepend string
+//	Y"layout" append date in Go layout (default 20060102) - file modification time or `--date-range`
+//
+// Positions N are 0-9 and A-Z (10-35)
+type mutationRule struct {
+	rule string
+	ops  []ruleOp
+}
+
+type ruleOp struct {
+	name byte
+	pos  int
+	x, y byte
+	str  string
+}
+
+// Parsed `--mutation-rules` file
+var mutationRules []mutationRule
+
+// Load rules from file. Empty lines and comments "#" are ignored
+func loadMutationRules(fpath string) ([]mutationRule, error) {
+	if fpath == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []mutationRule
+	lineNo := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := parseMutationRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d [%s]: %v", lineNo, line, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// Parse one rule line to list of functions
+func parseMutationRule(line string) (mutationRule, error) {
+	rule := mutationRule{rule: line}
+	s := line
+
+	// read N next bytes as arguments
+	args := func(n int) (string, error) {
+		if len(s) < n {
+			return "", fmt.Errorf("function [%c] needs %d argument(s)", rule.ops[len(rule.ops)-1].name, n)
+		}
+		a := s[:n]
+		s = s[n:]
+		return a, nil
+	}
+
+	for len(s) > 0 {
+		name := s[0]
+		s = s[1:]
+		if name == ' ' || name == '\t' {
+			continue
+		}
+		op := ruleOp{name: name}
+		rule.ops = append(rule.ops, op)
+
+		var a string
+		var err error
+		switch name {
+		case ':', 'l', 'u', 'c', 'C', 't', 'r', 'd', 'f', '[', ']':
+			// no arguments
+		case 'T', 'D':
+			if a, err = args(1); err == nil {
+				op.pos, err = rulePosition(a[0])
+			}
+		case '$', '^', '@':
+			if a, err = args(1); err == nil {
+				op.x = a[0]
+			}
+		case 's':
+			if a, err = args(2); err == nil {
+				op.x, op.y = a[0], a[1]
+			}
+		case 'i', 'o':
+			if a, err = args(2); err == nil {
+				op.pos, err = rulePosition(a[0])
+				op.x = a[1]
+			}
+		case 'A', 'P', 'Y':
+			op.str, err = ruleQuotedArg(&s)
+			if name == 'Y' && op.str == "" {
+				op.str = "20060102"
+			}
+		default:
+			err = fmt.Errorf("unknown function [%c]", name)
+		}
+		if err != nil {
+			return rule, err
+		}
+		rule.ops[len(rule.ops)-1] = op
+	}
+
+	return rule, nil
+}
+
+// Position 0-9 or A-Z (10-35)
+func rulePosition(b byte) (int, error) {
+	switch {
+	case b >= '0' && b <= '9':
+		return int(b - '0'), nil
+	case b >= 'A' && b <= 'Z':
+		return int(b-'A') + 10, nil
+	}
+	return 0, fmt.Errorf("invalid position [%c]", b)
+}
+
+// Optional "quoted" argument
+func ruleQuotedArg(s *string) (string, error) {
+	if !strings.HasPrefix(*s, `"`) {
+		return "", nil
+	}
+	end := strings.Index((*s)[1:], `"`)
+	if end < 0 {
+		return "", fmt.Errorf("missing closing quote")
+	}
+	a := (*s)[1 : end+1]
+	*s = (*s)[end+2:]
+	return a, nil
+}
+
+// Apply rule to filename. Date function gives one result per date
+// Empty result (all characters deleted) is dropped
+func (rule mutationRule) apply(fname string, modTime time.Time) []string {
+	words := []string{fname}
+	for _, op := range rule.ops {
+		var next []string
+		for _, w := range words {
+			if op.name == 'Y' {
+				for _, date := range mutationDates(modTime) {
+					next = append(next, w+date.Format(op.str))
+				}
+				continue
+			}
+			next = append(next, op.applyOne(w))
+		}
+		words = next
+	}
+
+	var results []string
+	for _, w := range words {
+		if w != "" && !inSlice(w, results) {
+			results = append(results, w)
+		}
+	}
+	return results
+}
+
+func (op ruleOp) applyOne(w string) string {
+	b := []byte(w)
+	switch op.name {
+	case 'l':
+		return strings.ToLower(w)
+	case 'u':
+		return strings.ToUpper(w)
+	case 'c':
+		if w == "" {
+			return w
+		}
+		return strings.ToUpper(w[:1]) + strings.ToLower(w[1:])
+	case 'C':
+		if w == "" {
+			return w
+		}
+		return strings.ToLower(w[:1]) + strings.ToUpper(w[1:])
+	case 't':
+		for i := range b {
+			b[i] = toggleCase(b[i])
+		}
+		return string(b)
+	case 'T':
+		if op.pos < len(b) {
+			b[op.pos] = toggleCase(b[op.pos])
+		}
+		return string(b)
+	case 'r':
+		return reverseString(w)
+	case 'd':
+		return w + w
+	case 'f':
+		return w + reverseString(w)
+	case '$':
+		return w + string(op.x)
+	case '^':
+		return string(op.x) + w
+	case '[':
+		if w == "" {
+			return w
+		}
+		return w[1:]
+	case ']':
+		if w == "" {
+			return w
+		}
+		return w[:len(w)-1]
+	case 'D':
+		if op.pos < len(w) {
+			return w[:op.pos] + w[op.pos+1:]
+		}
+	case 'i':
+		if op.pos <= len(w) {
+			return w[:op.pos] + string(op.x) + w[op.pos:]
+		}
+	case 'o':
+		if op.pos < len(b) {
+			b[op.pos] = op.x
+		}
+		return string(b)
+	case 's':
+		return strings.Replace(w, string(op.x), string(op.y), -1)
+	case '@':
+		return strings.Replace(w, string(op.x), "", -1)
+	case 'A':
+		return w + op.str
+	case 'P':
+		return op.str + w
+	}
+	return w
+}
+
+func toggleCase(c byte) byte {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return c - 'a' + 'A'
+	case c >= 'A' && c <= 'Z':
+		return c - 'A' + 'a'
+	}
+	return c
+}
+
+func reverseString(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseMutationRule(t *testing.T) {
+	mutationDateRange = nil
+	modTime := time.Date(2019, 10, 5, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		rule  string
+		fname string
+		want  []string
+	}{
+		{":", "config.php", []string{"config.php"}},
+		{"u", "config.php", []string{"CONFIG.PHP"}},
+		{"c", "CONFIG.php", []string{"Config.php"}},
+		{"T0", "config.php", []string{"Config.php"}},
+		{"$~", "config.php", []string{"config.php~"}},
+		{"^_ $~", "config.php", []string{"_config.php~"}},
+		{"] ] ]", "config.php", []string{"config."}},
+		{"sp_", "config.php", []string{"config._h_"}},
+		{"@.", "config.php", []string{"configphp"}},
+		{"i6-", "config.php", []string{"config-.php"}},
+		{"oA_", "config.php.bak", []string{"config.php_bak"}},
+
+		// quoted arguments keep spaces and function letters
+		{`A".bak"`, "config.php", []string{"config.php.bak"}},
+		{`P"copy of "`, "config.php", []string{"copy of config.php"}},
+		{`A"u l" u`, "a", []string{"AU L"}},
+		{`Y"-2006-01-02"`, "dump.sql", []string{"dump.sql-2019-10-05"}},
+		{"Y", "dump.sql", []string{"dump.sql20191005"}},
+
+		// all characters deleted
+		{"[ [", "ab", nil},
+	}
+
+	for _, tt := range tests {
+		rule, err := parseMutationRule(tt.rule)
+		if err != nil {
+			t.Errorf("parseMutationRule(%q) error: %v", tt.rule, err)
+			continue
+		}
+		got := rule.apply(tt.fname, modTime)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("rule %q on %q = %q, want %q", tt.rule, tt.fname, got, tt.want)
+		}
+	}
+}
+
+func TestParseMutationRuleErrors(t *testing.T) {
+	for _, rule := range []string{
+		"x",         // unknown function
+		"$",         // missing argument
+		"s.",        // missing second argument
+		"T!",        // invalid position
+		`A"open`,    // missing closing quote
+		`A".bak" %`, // unknown function after quoted argument
+	} {
+		if _, err := parseMutationRule(rule); err == nil {
+			t.Errorf("parseMutationRule(%q) expected error", rule)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// URL path waiting to be checked
+type candidate struct {
+	mutation           // URL path and mutation origin (for hit-rate stats)
+	note     string    // where candidate came from (route, reference)
+	srcPath  string    // source file candidate is derived from (empty for routes)
+	class    fileClass // sensitive file class from catalog
+	score    int
+}
+
+// Candidates collected while walking source
+// With `--priority` checked from highest score, otherwise in walk order
+var scanQueue []candidate
+var scanQueueSeen = map[string]bool{}
+
+// Keywords anywhere in filename
+var rxPriorityKeywords = []struct {
+	rx    *regexp.Regexp
+	bonus int
+}{
+	{regexp.MustCompile(`passw|secret|credential|private|token|apikey|api_key`), 40},
+	{regexp.MustCompile(`backup|dump|export|archive|copy`), 30},
+	{regexp.MustCompile(`config|setting|admin|debug|install|test`), 15},
+}
+
+// Risk score of URL path. Higher is checked first
+// Severity of file class, keyword bonus and penalty for depth
+func priorityScore(upath string, class fileClass) int {
+	name := strings.ToLower(path.Base(strings.TrimSuffix(upath, "/")))
+
+	score := class.severity * 25
+	for _, k := range rxPriorityKeywords {
+		if k.rx.MatchString(name) {
+			score += k.bonus
+			break
+		}
+	}
+
+	depth := strings.Count(strings.Trim(upath, "/"), "/")
+	return score - depth*3
+}
+
+// Add URL paths to scan queue. Already queued paths are skipped
+func queueURLMutations(umutations []mutation, note, srcPath string) {
+	for _, m := range umutations {
+		if scanQueueSeen[m.fpath] {
+			continue
+		}
+		scanQueueSeen[m.fpath] = true
+		class := classifyPath(m.fpath)
+		scanQueue = append(scanQueue, candidate{m, note, srcPath, class, priorityScore(m.fpath, class)})
+	}
+}
+
+// Order queue by score. Walk order kept for equal scores
+func sortScanQueue() {
+	if !argPriority {
+		return
+	}
+	sort.SliceStable(scanQueue, func(i, j int) bool {
+		return scanQueue[i].score > scanQueue[j].score
+	})
+}
+
+// Count of requests needed to check queued paths
+func countScanQueue() int {
+	count := 0
+	for _, c := range scanQueue {
+		count += 1 + len(urlPathVariants(c.fpath, caseUnknown))
+	}
+	return count * len(scanVhosts()) * len(targets)
+}
+
+// Check queued paths with their bypass variants (if enabled)
+// In vhost mode every path is checked with every Host header
+// Results are recorded to hit-rate stats by mutation origin
+func checkScanQueue(t *target) {
+	for _, c := range scanQueue {
+		results := map[string]*scanResult{}
+		for _, host := range scanVhosts() {
+			t.requestHost = host
+			result := checkURL(t, c)
+			results[host] = result
+			recordHit(t, c.mutation, result)
+			checkURLVariants(t, c, result)
+		}
+		t.requestHost = ""
+		compareVhostResults(t, c.fpath, results)
+	}
+}
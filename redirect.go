@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Redirects to the same location from this many paths are soft-404 (login or home page)
+const redirectClusterMin = 5
+
+// Redirect policy of `--follow-redirects` for http client
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	switch {
+	case argFollowRedirects == "none":
+		return http.ErrUseLastResponse
+	case argFollowRedirects == "same-host" && req.URL.Host != via[0].URL.Host:
+		return http.ErrUseLastResponse
+	case len(via) > argMaxRedirects:
+		return http.ErrUseLastResponse
+	}
+	return nil
+}
+
+// URLs response was redirected through (not including requested URL)
+// Location of not followed redirect is the last one
+func redirectChain(resp *http.Response) []string {
+	var chain []string
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		chain = append([]string{req.URL.String()}, chain...)
+	}
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		if loc, err := resp.Location(); err == nil {
+			chain = append(chain, loc.String())
+		}
+	}
+	return chain
+}
+
+// Record redirect of path and report if its location is soft-404
+// Earlier findings redirected to location are dropped once it is detected
+func isSoftRedirect(t *target, upath, location string) bool {
+	key, ok := redirectKey(location)
+	if !ok {
+		return false
+	}
+
+	if t.redirectTargets[key] == nil {
+		t.redirectTargets[key] = map[string]bool{}
+	}
+	t.redirectTargets[key][upath] = true
+
+	if len(t.redirectTargets[key]) < redirectClusterMin {
+		return false
+	}
+	if !t.softRedirects[key] {
+		t.softRedirects[key] = true
+		dropped := dropRedirectFindings(t, key)
+		t.log(color.CyanString("-- Redirects to %s look like soft-404 (%d paths). Skipped from now, %d earlier hits dropped --", key, len(t.redirectTargets[key]), dropped))
+	}
+	return true
+}
+
+// Redirect location without query ("/login?next=/x" is the same for all paths)
+func redirectKey(location string) (string, bool) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", false
+	}
+	u.RawQuery, u.Fragment = "", ""
+	return u.String(), true
+}
+
+// "[REDIRECT -> /a -> /login]"
+func redirectNote(chain []string) string {
+	return fmt.Sprintf("[REDIRECT -> %s]", strings.Join(chain, " -> "))
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Path found in source file content
+type reference struct {
+	fpath  string // source path or URL path if `isURL`
+	isURL  bool   // absolute URL path (e.g. src="/uploads/x.png") - no web root mapping needed
+	source string // file where reference found
+}
+
+// Collected while walking, queued after source walk
+var references []reference
+var referencesSeen = map[string]bool{}
+
+// All paths that exists in source (these are checked by walk anyway)
+var sourcePaths = map[string]bool{}
+
+// Only text files are scanned for references
+var referenceFileExts = []string{
+	".php", ".phtml", ".inc", ".js", ".mjs", ".ts", ".jsx", ".tsx", ".vue", ".py", ".rb", ".java", ".go",
+	".html", ".htm", ".tpl", ".twig", ".blade", ".jsp", ".asp", ".aspx", ".erb",
+	".ini", ".conf", ".config", ".cfg", ".yml", ".yaml", ".json", ".xml", ".env", ".dist", ".example", ".htaccess",
+}
+
+var rxReferences = []struct {
+	rx    *regexp.Regexp
+	isURL bool // leading slash means URL root instead of filesystem root
+}{
+	// include/require (PHP). Path after `__DIR__ .` is relative to file even with leading slash
+	{regexp.MustCompile(`\b(?:include|require)(?:_once)?\s*\(?\s*((?:__DIR__|dirname\(__FILE__\))\s*\.\s*)?['"]([^'"$]+)['"]`), false},
+	// import/require (JS) - only relative ones, others are packages
+	{regexp.MustCompile(`(?:\bimport\s+(?:[^'"]*?\s+from\s+)?|\brequire\(\s*)['"](\.{1,2}/[^'"]+)['"]`), false},
+	// HTML/JS attributes
+	{regexp.MustCompile(`(?i)\b(?:src|href|action|data-src|poster)\s*=\s*['"]([^'"#?{}<>$]+)`), true},
+	// config keys pointing to paths
+	{regexp.MustCompile(`(?i)['"]?[\w.-]*(?:path|file|dir|log|upload|cache|storage|backup|dump)[\w.-]*['"]?\s*(?:=>|=|:)\s*['"]?((?:[\w.-]+/)+[\w.-]*|[\w-]+\.[a-z0-9]{2,6})['"]?`), false},
+	// quoted file names with interesting extensions
+	{regexp.MustCompile(`['"]((?:\.{0,2}/)?(?:[\w.-]+/)*[\w.-]*\.(?:env|php|ini|ya?ml|json|xml|log|sql|conf|config|cfg|bak|old|txt|sqlite3?|db|key|pem|csv|zip|tar|gz))['"]`), false},
+}
+
+// Read source file and collect path-like strings from its content
+func collectReferences(fpath string) {
+	ext := strings.ToLower(filepath.Ext(fpath))
+	if !inSlice(ext, referenceFileExts) && !strings.HasPrefix(filepath.Base(fpath), ".env") {
+		return
+	}
+
+	data, err := source.ReadFile(fpath)
+	if err != nil {
+		return
+	}
+
+	for _, ref := range extractReferences(fpath, data) {
+		key := fmt.Sprintf("%v:%s", ref.isURL, ref.fpath)
+		if referencesSeen[key] {
+			continue
+		}
+		referencesSeen[key] = true
+		references = append(references, ref)
+	}
+}
+
+// Extract references from content and resolve them relative to file
+func extractReferences(fpath string, data []byte) []reference {
+	var refs []reference
+	basedir := filepath.Dir(fpath)
+
+	for _, r := range rxReferences {
+		for _, m := range r.rx.FindAllSubmatch(data, -1) {
+			s := strings.TrimSpace(string(m[len(m)-1]))
+			if len(m) > 2 && len(m[1]) > 0 {
+				s = strings.TrimLeft(s, "/") // relative to __DIR__
+			}
+			if s == "" || strings.Contains(s, "://") || strings.HasPrefix(s, "//") || strings.Contains(s, ":") {
+				continue // other host, mailto:, javascript:, data: etc.
+			}
+
+			ref := reference{source: fpath}
+			switch {
+			case strings.HasPrefix(s, "/") && r.isURL:
+				ref.isURL = true
+				ref.fpath = strings.TrimLeft(filepath.ToSlash(filepath.Clean(s)), "/")
+			case strings.HasPrefix(s, "/"):
+				continue // absolute filesystem path - outside web root
+			default:
+				resolved := filepath.ToSlash(filepath.Clean(filepath.Join(basedir, s)))
+				if strings.HasPrefix(resolved, "../") || resolved == ".." {
+					continue // outside source tree
+				}
+				ref.fpath = resolved
+			}
+
+			if ref.fpath == "" || ref.fpath == "." {
+				continue
+			}
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs
+}
+
+// Drop references to paths that exists in source (checked by walk)
+func filterReferences() {
+	// source paths as they are seen from URL
+	urlPaths := map[string]bool{}
+	for fpath := range sourcePaths {
+		if upath, served := mapToURLPath(fpath); served {
+			urlPaths[upath] = true
+		}
+	}
+
+	var refs []reference
+	for _, ref := range references {
+		if (!ref.isURL && sourcePaths[ref.fpath]) || (ref.isURL && urlPaths[ref.fpath]) {
+			continue
+		}
+		name := filepath.Base(ref.fpath)
+		if inSlice(name, argSkip) || inSlice(strings.ToLower(filepath.Ext(name)), argSkipExts) {
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	references = refs
+}
+
+// Queue all referenced paths with mutations
+func queueReferences() {
+	filterReferences()
+	for _, ref := range references {
+		srcPath := ref.fpath
+		if ref.isURL {
+			srcPath = "" // not known in source
+		}
+		queueURLMutations(ref.urlMutations(), fmt.Sprintf("[REF by %s]", ref.source), srcPath)
+	}
+}
+
+// Mutations of referenced path as URL paths
+func (ref reference) urlMutations() []mutation {
+	mutations := explainFilePathMutations(ref.fpath, time.Time{}, argBackups)
+	if ref.isURL {
+		return mutations // already URL paths
+	}
+	return urlMutations(mutations, mapToURLPath)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	_ "embed" // related files database
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Files that points there could be more similar or related files
+// Database is embedded and can be extended with `--related-db`
+//
+//go:embed data/related-files.yaml
+var relatedFilesYAML []byte
+
+type relatedDB struct {
+	Version string         `yaml:"version"`
+	Related []relatedEntry `yaml:"related"`
+}
+
+// Trigger is file or directory name (glob allowed)
+// Related files are relative to trigger file directory or trigger directory itself
+type relatedEntry struct {
+	File  string   `yaml:"file"`
+	Dir   string   `yaml:"dir"`
+	Files []string `yaml:"files"`
+}
+
+// Loaded database (embedded + extra)
+var relatedFiles relatedDB
+
+// Load embedded database and append entries from extra file (if given)
+func loadRelatedDB(extraPath string) error {
+	relatedFiles = relatedDB{}
+	if err := yaml.UnmarshalStrict(relatedFilesYAML, &relatedFiles); err != nil {
+		return fmt.Errorf("embedded database: %v", err)
+	}
+
+	if extraPath == "" {
+		return nil
+	}
+
+	buf, err := ioutil.ReadFile(extraPath)
+	if err != nil {
+		return err
+	}
+	var extra relatedDB
+	if err := yaml.UnmarshalStrict(buf, &extra); err != nil {
+		return fmt.Errorf("%s: %v", extraPath, err)
+	}
+	for _, entry := range extra.Related {
+		if (entry.File == "") == (entry.Dir == "") {
+			return fmt.Errorf("%s: entry must have exactly one of `file` or `dir` (files: %v)", extraPath, entry.Files)
+		}
+	}
+	relatedFiles.Related = append(relatedFiles.Related, extra.Related...)
+
+	return nil
+}
+
+// Related files of given source path
+// File triggers gives siblings, directory triggers gives files inside directory
+func relatedFilesFor(fpath string, isDir bool) []string {
+	name := filepath.Base(fpath)
+	basedir := filepath.Dir(fpath)
+	if isDir {
+		basedir = fpath
+	}
+
+	var related []string
+	for _, entry := range relatedFiles.Related {
+		pattern := entry.File
+		if isDir {
+			pattern = entry.Dir
+		}
+		if pattern == "" {
+			continue
+		}
+		if matched, _ := path.Match(pattern, name); !matched {
+			continue
+		}
+
+		for _, rel := range entry.Files {
+			rel = filepath.ToSlash(filepath.Join(basedir, rel))
+			if rel != fpath && !inSlice(rel, related) {
+				related = append(related, rel)
+			}
+		}
+	}
+	return related
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Pinned addresses from `--resolve` ("host:port" -> "ip:port")
+var resolveMap = map[string]string{}
+
+// Parse curl-style "host:port:ip" (IPv6 in brackets "host:443:[::1]")
+func parseResolve(args []string) (map[string]string, error) {
+	m := map[string]string{}
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		if arg == "" {
+			continue
+		}
+		parts := strings.SplitN(arg, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid value [%s] (expected: host:port:ip)", arg)
+		}
+		ip := strings.Trim(parts[2], "[]")
+		if net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("invalid IP [%s] in [%s]", ip, arg)
+		}
+		m[net.JoinHostPort(strings.ToLower(parts[0]), parts[1])] = net.JoinHostPort(ip, parts[1])
+	}
+	return m, nil
+}
+
+// Address to connect to ("host:port" or pinned "ip:port")
+func resolveAddr(addr string) string {
+	if pinned, ok := resolveMap[strings.ToLower(addr)]; ok {
+		return pinned
+	}
+	return addr
+}
+
+// Dialer using `--resolve` addresses
+func dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: time.Duration(argTimeout) * time.Second}
+	return dialer.DialContext(ctx, network, resolveAddr(addr))
+}
+
+// Host header values of `--vhost` and `--vhosts-file`
+var vhosts []string
+
+// Load vhosts from flag values and file (one per line, comments "#" ignored)
+func loadVhosts(args []string, fpath string) ([]string, error) {
+	var hosts []string
+	add := func(h string) {
+		if h = strings.TrimSpace(h); h != "" && !strings.HasPrefix(h, "#") && !inSlice(h, hosts) {
+			hosts = append(hosts, h)
+		}
+	}
+	for _, h := range args {
+		add(h)
+	}
+
+	if fpath != "" {
+		f, err := os.Open(fpath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			add(scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return hosts, nil
+}
+
+// Vhosts to check every candidate with (one empty value without vhost mode)
+func scanVhosts() []string {
+	if len(vhosts) == 0 {
+		return []string{""}
+	}
+	return vhosts
+}
+
+// Print vhosts with different response for the same path
+func compareVhostResults(t *target, upath string, results map[string]*scanResult) {
+	if len(results) < 2 {
+		return
+	}
+
+	var parts []string
+	differs := false
+	var first *scanResult
+	for _, host := range vhosts {
+		result := results[host]
+		if result == nil || result.Err != nil {
+			continue
+		}
+		if first == nil {
+			first = result
+		} else if result.Code != first.Code || result.Size != first.Size {
+			differs = true
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d/%d", host, result.Code, result.Size))
+	}
+	if differs {
+		t.log(color.MagentaString("VHOST DIFF: %s%s -- %s", t.endpoint, upath, strings.Join(parts, ", ")))
+	}
+}
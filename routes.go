@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Route definitions parser for one framework
+type routeParser struct {
+	name  string
+	match func(fpath string) bool // which source files hold routes
+	rx    []*regexp.Regexp        // last submatch is route path
+}
+
+var routeParsers = []routeParser{
+	{
+		name: "laravel",
+		match: func(fpath string) bool {
+			return strings.HasPrefix(fpath, "routes/") && filepath.Ext(fpath) == ".php"
+		},
+		rx: []*regexp.Regexp{
+			regexp.MustCompile(`Route::(?:get|post|put|patch|delete|options|any|match|view|redirect|resource|apiResource)\(\s*(?:\[[^\]]*\]\s*,\s*)?['"]([^'"]+)['"]`),
+			regexp.MustCompile(`Route::prefix\(\s*['"]([^'"]+)['"]`),
+		},
+	},
+	{
+		name: "express",
+		match: func(fpath string) bool {
+			return inSlice(filepath.Ext(fpath), []string{".js", ".ts", ".mjs"})
+		},
+		rx: []*regexp.Regexp{
+			regexp.MustCompile(`\b(?:app|router|route|server)\.(?:get|post|put|patch|delete|all|use|route)\(\s*['"` + "`" + `](/[^'"` + "`" + `]*)['"` + "`" + `]`),
+		},
+	},
+	{
+		name: "flask",
+		match: func(fpath string) bool {
+			return filepath.Ext(fpath) == ".py" && filepath.Base(fpath) != "urls.py"
+		},
+		rx: []*regexp.Regexp{
+			regexp.MustCompile(`@\w+\.(?:route|get|post|put|patch|delete)\(\s*['"]([^'"]+)['"]`),
+		},
+	},
+	{
+		name: "django",
+		match: func(fpath string) bool {
+			return filepath.Base(fpath) == "urls.py"
+		},
+		rx: []*regexp.Regexp{
+			regexp.MustCompile(`\b(?:path|re_path|url)\(\s*r?['"]([^'"]*)['"]`),
+		},
+	},
+	{
+		name: "spring",
+		match: func(fpath string) bool {
+			return inSlice(filepath.Ext(fpath), []string{".java", ".kt"})
+		},
+		rx: []*regexp.Regexp{
+			regexp.MustCompile(`@(?:Request|Get|Post|Put|Patch|Delete)Mapping\(\s*(?:(?:value|path)\s*=\s*)?[\[{]?\s*"([^"]*)"`),
+		},
+	},
+}
+
+// Route placeholders replaced with sample value
+var rxRouteParams = []*regexp.Regexp{
+	regexp.MustCompile(`\(\?P<[^)]*\)`),    // (?P<id>\d+)
+	regexp.MustCompile(`\{[^}]*\}`),        // {id} {id?} {id:\d+}
+	regexp.MustCompile(`<[^>]*>`),          // <int:id>
+	regexp.MustCompile(`\([^)]*\)[?*+]?`),  // (\d+)
+	regexp.MustCompile(`:[A-Za-z_]\w*\??`), // :id
+}
+
+// Spring class level mapping used as prefix for method mappings
+var rxSpringClassMapping = regexp.MustCompile(`(?s)@RequestMapping\(\s*(?:(?:value|path)\s*=\s*)?[\[{]?\s*"([^"]*)"[^)]*\)\s*(?:@\w+(?:\([^)]*\))?\s*)*(?:public\s+)?(?:abstract\s+)?class\b`)
+
+// Extract URL paths (relative to endpoint root) from source file content
+// Returns framework name with found routes
+func extractRoutes(fpath string, data []byte) (string, []string) {
+	for _, parser := range routeParsers {
+		if !parser.match(fpath) {
+			continue
+		}
+
+		content := string(data)
+		prefix := ""
+		if parser.name == "spring" {
+			if m := rxSpringClassMapping.FindStringSubmatchIndex(content); m != nil {
+				prefix = content[m[2]:m[3]]
+				content = content[m[1]:] // class mapping is not a route itself
+			}
+		}
+
+		var routes []string
+		for _, rx := range parser.rx {
+			for _, m := range rx.FindAllStringSubmatch(content, -1) {
+				if route := normalizeRoute(prefix + "/" + m[len(m)-1]); route != "" {
+					routes = append(routes, route)
+				}
+			}
+		}
+		if len(routes) > 0 {
+			return parser.name, routes
+		}
+	}
+	return "", nil
+}
+
+// "/user/{id}/" -> "user/1/", "^admin/$" -> "admin/"
+func normalizeRoute(route string) string {
+	route = strings.Replace(route, "^", "", -1)
+	route = strings.Replace(route, "$", "", -1)
+	route = strings.Replace(route, `\`, "", -1)
+	for _, rx := range rxRouteParams {
+		route = rx.ReplaceAllString(route, "1")
+	}
+
+	// express wildcard and regex leftovers
+	route = strings.Replace(route, "*", "", -1)
+	for strings.Contains(route, "//") {
+		route = strings.Replace(route, "//", "/", -1)
+	}
+
+	return strings.TrimLeft(route, "/")
+}
+
+// Parse routes from source file and queue them
+func visitRoutes(fpath string) {
+	matched := false
+	for _, parser := range routeParsers {
+		matched = matched || parser.match(fpath)
+	}
+	if !matched {
+		return
+	}
+
+	data, err := source.ReadFile(fpath)
+	if err != nil {
+		return
+	}
+
+	framework, routes := extractRoutes(fpath, data)
+	if framework != "" && scanFramework == "" {
+		scanFramework = framework
+	}
+	for _, route := range routes {
+		queueURLMutations([]mutation{{route, "original"}}, fmt.Sprintf("[ROUTE %s %s]", framework, fpath), "")
+	}
+}
+
+// Framework of source detected by first file with routes
+// Used as part of hit-rate stats profile before scan starts
+func detectFramework(src scanSource) string {
+	framework := ""
+	src.Walk(func(fpath string, f os.FileInfo, err error) error {
+		if err != nil || f.IsDir() {
+			return nil
+		}
+		matched := false
+		for _, parser := range routeParsers {
+			matched = matched || parser.match(fpath)
+		}
+		if !matched {
+			return nil
+		}
+		if data, err := src.ReadFile(fpath); err == nil {
+			framework, _ = extractRoutes(fpath, data)
+		}
+		if framework != "" {
+			return io.EOF // found - stop walk
+		}
+		return nil
+	})
+	return framework
+}
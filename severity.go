@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Severity levels of found files (ordered)
+const severityInfo = 0
+const severityLow = 1
+const severityMedium = 2
+const severityHigh = 3
+const severityCritical = 4
+
+var severityNames = []string{"info", "low", "medium", "high", "critical"}
+
+// Class of sensitive files matched by glob of lowercase filename
+// or by directory name anywhere in path (VCS metadata)
+type fileClass struct {
+	name     string
+	severity int
+	names    []string
+	dirs     []string
+}
+
+// Catalog of sensitive file classes. First matching class is used
+// so more specific classes go first
+var fileClasses = []fileClass{
+	{
+		name:     "private-key",
+		severity: severityCritical,
+		names:    []string{"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519", "*.pem", "*.key", "*.p12", "*.pfx", "*.ppk", "*.jks", "*.keystore", "*.kdbx"},
+	},
+	{
+		name:     "credentials",
+		severity: severityCritical,
+		names: []string{
+			".env", ".env.*", ".htpasswd", "credentials", "credentials.*", ".git-credentials", "secrets.yml", "secrets.json", "master.key",
+			".npmrc", ".pypirc", ".pgpass", ".netrc", ".my.cnf", "auth.json", "wp-config.php", "local_settings.py", "database.yml", "parameters.yml",
+		},
+	},
+	{
+		name:     "database-dump",
+		severity: severityCritical,
+		names:    []string{"*.sql", "*.sql.gz", "*.sql.zip", "*.sql.bz2", "*.dump", "*.sqlite", "*.sqlite3", "*.db", "*.mdb"},
+	},
+	{
+		name:     "vcs-metadata",
+		severity: severityHigh,
+		names:    []string{".git", ".svn", ".hg", ".bzr", "cvs", ".gitconfig"},
+		dirs:     []string{".git", ".svn", ".hg", ".bzr", "cvs"},
+	},
+	{
+		name:     "source-disclosure",
+		severity: severityHigh,
+		names:    []string{".*.swp", ".*.swo", ".*.swn", "#*#", ".#*", "*~", "*.~*~", "._*", "*.php.txt", "*.phps", "*.inc"},
+	},
+	{
+		name:     "backup",
+		severity: severityHigh,
+		names: []string{
+			"*.bak", "*.backup", "*.bkp", "*.old", "*.orig", "*.save", "*.tmp", "*.dmp", "*_old",
+			"*.zip", "*.tar", "*.tar.gz", "*.tgz", "*.tar.bz2", "*.rar", "*.7z", "*.gz",
+		},
+	},
+	{
+		name:     "config",
+		severity: severityMedium,
+		names: []string{
+			"config.php", "configuration.php", "settings.php", "settings.py", "web.config", "appsettings*.json", "application*.properties", "application*.yml",
+			".htaccess", "docker-compose*.yml", "dockerfile", "*.ini", "*.conf", "*.config", "*.cfg", "*.properties", "*.toml",
+		},
+	},
+	{
+		name:     "logs",
+		severity: severityMedium,
+		names:    []string{"*.log", "*.log.*", "error_log", "access_log", "debug.log", "npm-debug.log*"},
+	},
+	{
+		name:     "info",
+		severity: severityLow,
+		names:    []string{"phpinfo.php", "info.php", ".ds_store", "composer.lock", "composer.json", "package.json", "package-lock.json", "yarn.lock", "readme*", "changelog*"},
+	},
+}
+
+// Not in catalog
+var fileClassOther = fileClass{name: "other", severity: severityInfo}
+
+// Class of URL path (first matching in catalog)
+func classifyPath(upath string) fileClass {
+	upath = strings.ToLower(strings.Trim(upath, "/"))
+	name := path.Base(upath)
+	segments := strings.Split(upath, "/")
+
+	for _, class := range fileClasses {
+		for _, pattern := range class.names {
+			if matched, _ := path.Match(pattern, name); matched {
+				return class
+			}
+		}
+		for _, dir := range class.dirs {
+			if inSlice(dir, segments[:len(segments)-1]) {
+				return class
+			}
+		}
+	}
+	return fileClassOther
+}
+
+// "high" -> severityHigh
+func parseSeverity(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for i, name := range severityNames {
+		if name == s {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown severity [%s] (expected: %s)", s, strings.Join(severityNames, ", "))
+}
+
+// Parsed `--min-severity`
+var minSeverity = severityInfo
+
+// Text colored by severity
+func severityColor(severity int, format string, a ...interface{}) string {
+	switch severity {
+	case severityCritical:
+		return color.New(color.FgHiRed, color.Bold).Sprintf(format, a...)
+	case severityHigh:
+		return color.RedString(format, a...)
+	case severityMedium:
+		return color.YellowString(format, a...)
+	case severityLow:
+		return color.CyanString(format, a...)
+	}
+	return color.WhiteString(format, a...)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Zip archive as source. Archive stays open while scanning
+// and file contents are read on demand
+func newZipSource(fpath string) (scanSource, error) {
+	zr, err := zip.OpenReader(fpath)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]*zip.File{}
+	src := newTreeSource("zip")
+	for _, zf := range zr.File {
+		src.add(zf.Name, zf.FileInfo(), nil)
+		files[strings.Trim(zf.Name, "/")] = zf
+	}
+
+	// file map still holds original (not stripped) paths
+	prefix := src.stripSingleRoot()
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	src.read = func(fpath string) ([]byte, error) {
+		zf, exists := files[prefix+strings.Trim(fpath, "/")]
+		if !exists {
+			return nil, os.ErrNotExist
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return ioutil.ReadAll(io.LimitReader(rc, maxSourceReadSize))
+	}
+
+	return src, nil
+}
+
+// Tar (optionally gzipped) archive as source
+// Tar can't be read randomly so small files are cached while indexing
+func newTarSource(fpath string) (scanSource, error) {
+	src := newTreeSource("tar")
+	if err := readTarFile(fpath, func(hdr *tar.Header, r io.Reader) error {
+		var data []byte
+		if hdr.Typeflag == tar.TypeReg && hdr.Size <= maxSourceReadSize {
+			data, _ = ioutil.ReadAll(r)
+		}
+		src.add(hdr.Name, hdr.FileInfo(), data)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	src.stripSingleRoot()
+	return src, nil
+}
+
+// Iterate all tar entries. Gzip compression detected by magic bytes
+func readTarFile(fpath string, fn func(hdr *tar.Header, r io.Reader) error) error {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(f, magic); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		f.Seek(0, io.SeekStart)
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		f.Seek(0, io.SeekStart)
+	}
+
+	return readTar(r, fn)
+}
+
+// Return `io.EOF` from callback to stop reading
+func readTar(r io.Reader, fn func(hdr *tar.Header, r io.Reader) error) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(hdr, tr); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
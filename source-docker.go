@@ -0,0 +1,241 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Whiteout file prefixes used by image layers
+// (c) https://github.com/opencontainers/image-spec/blob/master/layer.md#whiteouts
+const whiteoutPrefix = ".wh."
+const whiteoutOpaque = ".wh..wh..opq"
+
+// Single image entry of `manifest.json` inside `docker save` tarball
+type dockerManifest struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// Tarball is not a `docker save` image (used to fall back to plain tar source)
+var errNotDockerImage = errors.New("not a docker image (missing manifest.json)")
+
+// Position of regular file content inside tarball
+type tarEntry struct {
+	offset int64
+	size   int64
+}
+
+// Filesystem of image saved with `docker save` as source
+// Tarball is indexed in one pass (manifest and entry offsets),
+// then layers are read by offset and merged in manifest order with whiteout handling
+// and only tree below `imagePath` (e.g. "/var/www/html") is used
+func newDockerSource(fpath, imagePath string) (scanSource, error) {
+	f, closeTar, err := openPlainTar(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer closeTar()
+
+	manifests, entries, err := indexDockerImage(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifests) == 0 || len(manifests[0].Layers) == 0 {
+		return nil, errNotDockerImage
+	}
+	manifest := manifests[0] // first image in tarball
+
+	imagePath = strings.Trim(filepath.ToSlash(filepath.Clean("/"+imagePath)), "/")
+
+	// All layers merged to one tree
+	merged := newTreeSource("docker")
+	for _, layer := range manifest.Layers {
+		entry, found := entries[filepath.Clean(layer)]
+		if !found {
+			return nil, fmt.Errorf("layer [%s]: not found in image", layer)
+		}
+		if err := mergeDockerLayer(merged, io.NewSectionReader(f, entry.offset, entry.size), imagePath); err != nil {
+			return nil, fmt.Errorf("layer [%s]: %v", layer, err)
+		}
+	}
+
+	if imagePath == "" {
+		return merged, nil
+	}
+
+	// Use only given path inside image as root
+	src := newTreeSource("docker")
+	for p, entry := range merged.entries {
+		if strings.HasPrefix(p, imagePath+"/") {
+			src.entries[strings.TrimPrefix(p, imagePath+"/")] = entry
+		}
+	}
+	if len(src.entries) == 0 {
+		return nil, fmt.Errorf("path [%s] not found or empty in image", "/"+imagePath)
+	}
+
+	return src, nil
+}
+
+// Apply one layer (plain or gzipped tar) on top of previous layers
+func mergeDockerLayer(merged *treeSource, r io.Reader, imagePath string) error {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	// Whiteouts hides only files from lower layers
+	// so collect this layer entries and add them after deletions
+	type layerEntry struct {
+		hdr  *tar.Header
+		data []byte
+	}
+	var entries []layerEntry
+
+	err := readTar(r, func(hdr *tar.Header, r io.Reader) error {
+		fpath := strings.Trim(filepath.ToSlash(filepath.Clean("/"+hdr.Name)), "/")
+		dir, name := filepath.Dir(fpath), filepath.Base(fpath)
+		if dir == "." {
+			dir = ""
+		}
+
+		switch {
+		case name == whiteoutOpaque:
+			// remove all children from lower layers but keep directory itself
+			for p := range merged.entries {
+				if dir == "" || strings.HasPrefix(p, dir+"/") {
+					delete(merged.entries, p)
+				}
+			}
+			return nil
+
+		case strings.HasPrefix(name, whiteoutPrefix):
+			merged.remove(filepath.Join(dir, strings.TrimPrefix(name, whiteoutPrefix)))
+			return nil
+		}
+
+		// cache content only for files that will be scanned
+		var data []byte
+		inScope := imagePath == "" || strings.HasPrefix(fpath, imagePath+"/")
+		if inScope && hdr.Typeflag == tar.TypeReg && hdr.Size <= maxSourceReadSize {
+			data, _ = ioutil.ReadAll(r)
+		}
+		entries = append(entries, layerEntry{hdr: hdr, data: data})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		// directory replaced by file (or vice versa) hides lower content
+		fpath := strings.Trim(filepath.ToSlash(filepath.Clean("/"+entry.hdr.Name)), "/")
+		if prev, exists := merged.entries[fpath]; exists {
+			if prev.info.IsDir() != entry.hdr.FileInfo().IsDir() {
+				merged.remove(fpath)
+			}
+		}
+		merged.add(entry.hdr.Name, entry.hdr.FileInfo(), entry.data)
+	}
+
+	return nil
+}
+
+// Read manifest and offsets of regular files in one pass
+// Content of entries is skipped by seeking (not read)
+func indexDockerImage(f *os.File) ([]dockerManifest, map[string]tarEntry, error) {
+	var manifests []dockerManifest
+	entries := map[string]tarEntry{}
+
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := filepath.Clean(hdr.Name)
+		if name == "manifest.json" {
+			// other manifest (e.g. web app manifest in plain archive) is not an image
+			if err := json.NewDecoder(tr).Decode(&manifests); err != nil {
+				manifests = nil
+			}
+			continue
+		}
+
+		// content starts right after header
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries[name] = tarEntry{offset, hdr.Size}
+	}
+	return manifests, entries, nil
+}
+
+// Open tarball for reading by offsets. Returned func closes (and removes) it
+// Gzipped tarball is decompressed once to temporary file
+func openPlainTar(fpath string) (*os.File, func(), error) {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(f, magic); err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return f, func() { f.Close() }, nil
+	}
+
+	defer f.Close()
+	f.Seek(0, io.SeekStart)
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer gz.Close()
+
+	tmp, err := ioutil.TempFile("", "findthese-image-*.tar")
+	if err != nil {
+		return nil, nil, err
+	}
+	closeTmp := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+	if _, err := io.Copy(tmp, gz); err != nil {
+		closeTmp()
+		return nil, nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		closeTmp()
+		return nil, nil, err
+	}
+	return tmp, closeTmp, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Local git repository at given ref (tag, branch, commit)
+// Tree is read with `git ls-tree` so no checkout is needed
+func newGitSource(repoPath, ref string) (scanSource, error) {
+	commit, err := gitCommand(repoPath, "rev-parse", "--verify", ref+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("git ref [%s]: %v", ref, err)
+	}
+	commit = bytes.TrimSpace(commit)
+
+	// Commit time used as modification time for all entries
+	var modTime time.Time
+	if out, err := gitCommand(repoPath, "log", "-1", "--format=%ct", string(commit)); err == nil {
+		if ts, err := strconv.ParseInt(string(bytes.TrimSpace(out)), 10, 64); err == nil {
+			modTime = time.Unix(ts, 0)
+		}
+	}
+
+	// <mode> SP <type> SP <object> SP <size> TAB <path>
+	out, err := gitCommand(repoPath, "ls-tree", "-r", "-t", "-z", "--long", string(commit))
+	if err != nil {
+		return nil, fmt.Errorf("git ls-tree [%s]: %v", ref, err)
+	}
+
+	src := newTreeSource("git")
+	for _, line := range bytes.Split(out, []byte{0}) {
+		parts := strings.SplitN(string(line), "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		meta := strings.Fields(parts[0])
+		if len(meta) != 4 {
+			continue
+		}
+		fpath := parts[1]
+		name := filepath.Base(fpath)
+
+		switch meta[1] {
+		case "tree":
+			src.add(fpath, newVirtualDirInfo(name, modTime), nil)
+		case "blob":
+			size, _ := strconv.ParseInt(meta[3], 10, 64)
+			src.add(fpath, newVirtualFileInfo(name, size, modTime), nil)
+		}
+		// "commit" type is submodule - not part of this repository
+	}
+
+	src.read = func(fpath string) ([]byte, error) {
+		data, err := gitCommand(repoPath, "show", string(commit)+":"+strings.Trim(fpath, "/"))
+		if len(data) > maxSourceReadSize {
+			data = data[:maxSourceReadSize]
+		}
+		return data, err
+	}
+
+	return src, nil
+}
+
+func gitCommand(repoPath string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", repoPath}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Path list (wordlist, crawl results) as source. One path per line
+// Lines ending with slash "/" are treated as directories
+// Full URLs are accepted - only path part is used
+// Use "-" as fpath to read from stdin
+func newListSource(kind, fpath string) (scanSource, error) {
+	var r io.Reader = os.Stdin
+	if fpath != "-" {
+		f, err := os.Open(fpath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	src := newTreeSource(kind)
+
+	// Listed paths have no modification date (date mutations only with `--date-range`)
+	var modTime time.Time
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// URL from previous crawl
+		if strings.Contains(line, "://") {
+			u, err := url.Parse(line)
+			if err != nil {
+				continue
+			}
+			line = u.EscapedPath()
+			if line == "" || line == "/" {
+				continue
+			}
+		}
+
+		isDir := strings.HasSuffix(line, "/")
+		line = strings.Trim(filepath.ToSlash(filepath.Clean("/"+line)), "/")
+		if line == "" {
+			continue
+		}
+
+		// No implicit parent directories - list is checked as it is
+		name := filepath.Base(line)
+		if isDir {
+			src.entries[line] = &treeEntry{info: newVirtualDirInfo(name, modTime)}
+		} else if _, exists := src.entries[line]; !exists {
+			src.entries[line] = &treeEntry{info: newVirtualFileInfo(name, 0, modTime)}
+		}
+	}
+
+	return src, scanner.Err()
+}
+
+// Several sources walked one after another
+type multiSource []scanSource
+
+func (sources multiSource) Type() string {
+	var types []string
+	for _, src := range sources {
+		types = append(types, src.Type())
+	}
+	return strings.Join(types, " + ")
+}
+
+func (sources multiSource) Walk(fn filepath.WalkFunc) error {
+	for _, src := range sources {
+		if err := src.Walk(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// First source that holds file wins
+func (sources multiSource) ReadFile(fpath string) ([]byte, error) {
+	for _, src := range sources {
+		if data, err := src.ReadFile(fpath); err == nil {
+			return data, nil
+		}
+	}
+	return nil, os.ErrNotExist
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Source of file tree which is used to generate URLs to check
+// All paths given to walk callback are relative to source root
+// and separated by slash "/" (e.g. "path/to/file.txt")
+type scanSource interface {
+	Walk(fn filepath.WalkFunc) error
+	ReadFile(fpath string) ([]byte, error)
+	Type() string
+}
+
+// Content of source entry is not known (e.g. path from wordlist)
+var errNoContent = errors.New("content not available")
+
+// Max file size read from source
+const maxSourceReadSize = 1 << 20 // 1MB
+
+// Detect source type by given path and options
+func openSource(fpath, ref, imagePath string) (scanSource, error) {
+	// Git repository at specific ref (no checkout needed)
+	if ref != "" {
+		return newGitSource(fpath, ref)
+	}
+
+	fi, err := os.Stat(fpath)
+	if err != nil {
+		return nil, err
+	}
+
+	if fi.IsDir() {
+		return &dirSource{root: fpath}, nil
+	}
+
+	lower := strings.ToLower(fpath)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return newZipSource(fpath)
+	case imagePath != "":
+		src, err := newDockerSource(fpath, imagePath)
+		if err == errNotDockerImage {
+			return nil, fmt.Errorf("%v: %s", err, fpath)
+		}
+		return src, err
+	case strings.HasSuffix(lower, ".tar"),
+		strings.HasSuffix(lower, ".tar.gz"),
+		strings.HasSuffix(lower, ".tgz"):
+		// `docker save` tarball (also gzipped) or plain archive
+		src, err := newDockerSource(fpath, "")
+		if err == errNotDockerImage {
+			return newTarSource(fpath)
+		}
+		return src, err
+	}
+
+	return nil, fmt.Errorf("unsupported source type: %s", fpath)
+}
+
+// --------------------------------------------------------------------------------
+// Local directory
+
+type dirSource struct {
+	root string
+}
+
+func (src *dirSource) Type() string {
+	return "directory"
+}
+
+// Walk local directory and pass only relative paths to callback
+func (src *dirSource) Walk(fn filepath.WalkFunc) error {
+	root := strings.TrimSuffix(src.root, "/") + "/"
+	return filepath.Walk(root, func(fpath string, f os.FileInfo, err error) error {
+		fpath = strings.TrimPrefix(fpath, root)
+		return fn(filepath.ToSlash(fpath), f, err)
+	})
+}
+
+func (src *dirSource) ReadFile(fpath string) ([]byte, error) {
+	f, err := os.Open(filepath.Join(src.root, filepath.FromSlash(fpath)))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(io.LimitReader(f, maxSourceReadSize))
+}
+
+// --------------------------------------------------------------------------------
+// In-memory tree built from archives, git refs etc.
+
+// One file or directory of in-memory tree
+type treeEntry struct {
+	info os.FileInfo
+	data []byte // cached content (if any)
+}
+
+type treeSource struct {
+	kind    string
+	entries map[string]*treeEntry
+
+	// Optional content reader for entries without cached data
+	read func(fpath string) ([]byte, error)
+}
+
+func newTreeSource(kind string) *treeSource {
+	return &treeSource{
+		kind:    kind,
+		entries: map[string]*treeEntry{},
+	}
+}
+
+func (src *treeSource) Type() string {
+	return src.kind
+}
+
+// Add entry to tree. Parent directories are created if missing
+func (src *treeSource) add(fpath string, info os.FileInfo, data []byte) {
+	fpath = strings.Trim(filepath.ToSlash(filepath.Clean("/"+fpath)), "/")
+	if fpath == "" || fpath == "." {
+		return
+	}
+
+	src.entries[fpath] = &treeEntry{info: info, data: data}
+
+	// implicit parent directories (archives not always have them)
+	for dir := filepath.Dir(fpath); dir != "." && dir != "/"; dir = filepath.Dir(dir) {
+		if _, exists := src.entries[dir]; exists {
+			break
+		}
+		src.entries[dir] = &treeEntry{info: newVirtualDirInfo(filepath.Base(dir), info.ModTime())}
+	}
+}
+
+// Remove entry and everything below it
+func (src *treeSource) remove(fpath string) {
+	fpath = strings.Trim(fpath, "/")
+	delete(src.entries, fpath)
+	for p := range src.entries {
+		if strings.HasPrefix(p, fpath+"/") {
+			delete(src.entries, p)
+		}
+	}
+}
+
+// Release archives usually wraps everything in single top folder
+// (e.g. "phpMyAdmin-4.9.0-all-languages/") which is not part of URL
+// Returns stripped folder name or empty string if nothing stripped
+func (src *treeSource) stripSingleRoot() string {
+	root := ""
+	for p := range src.entries {
+		top := strings.SplitN(p, "/", 2)[0]
+		if root != "" && top != root {
+			return "" // more than one top level item
+		}
+		root = top
+	}
+
+	if entry, exists := src.entries[root]; !exists || !entry.info.IsDir() {
+		return ""
+	}
+
+	entries := map[string]*treeEntry{}
+	for p, entry := range src.entries {
+		if p == root {
+			continue
+		}
+		entries[strings.TrimPrefix(p, root+"/")] = entry
+	}
+	src.entries = entries
+	return root
+}
+
+// Walk tree in the same order as `filepath.Walk` does
+// Supports `filepath.SkipDir` returned from callback
+func (src *treeSource) Walk(fn filepath.WalkFunc) error {
+	var paths []string
+	for p := range src.entries {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return pathLess(paths[i], paths[j])
+	})
+
+	// root
+	if err := fn("", newVirtualDirInfo(".", time.Time{}), nil); err != nil {
+		if err == filepath.SkipDir {
+			return nil
+		}
+		return err
+	}
+
+	skipPrefix := ""
+	for _, p := range paths {
+		if skipPrefix != "" && strings.HasPrefix(p, skipPrefix) {
+			continue
+		}
+		skipPrefix = ""
+
+		entry := src.entries[p]
+		err := fn(p, entry.info, nil)
+		if err == filepath.SkipDir {
+			if entry.info.IsDir() {
+				skipPrefix = p + "/"
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (src *treeSource) ReadFile(fpath string) ([]byte, error) {
+	entry, exists := src.entries[strings.Trim(fpath, "/")]
+	if !exists || entry.info.IsDir() {
+		return nil, os.ErrNotExist
+	}
+	if entry.data != nil {
+		return entry.data, nil
+	}
+	if src.read != nil {
+		return src.read(fpath)
+	}
+	return nil, errNoContent
+}
+
+// Compare paths component by component
+// so "a/b" goes right after "a" and before "a.b"
+func pathLess(a, b string) bool {
+	pa := strings.Split(a, "/")
+	pb := strings.Split(b, "/")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if pa[i] != pb[i] {
+			return pa[i] < pb[i]
+		}
+	}
+	return len(pa) < len(pb)
+}
+
+// --------------------------------------------------------------------------------
+// File info for entries that does not exist on local filesystem
+
+type virtualFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func newVirtualFileInfo(name string, size int64, modTime time.Time) os.FileInfo {
+	return &virtualFileInfo{name: name, size: size, mode: 0644, modTime: modTime}
+}
+
+func newVirtualDirInfo(name string, modTime time.Time) os.FileInfo {
+	return &virtualFileInfo{name: name, mode: os.ModeDir | 0755, modTime: modTime}
+}
+
+func (fi *virtualFileInfo) Name() string       { return fi.name }
+func (fi *virtualFileInfo) Size() int64        { return fi.size }
+func (fi *virtualFileInfo) Mode() os.FileMode  { return fi.mode }
+func (fi *virtualFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *virtualFileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi *virtualFileInfo) Sys() interface{}   { return nil }
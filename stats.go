@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Hit-rate statistics of mutation origins collected across scans
+// Stored per profile (server type and framework) in `--stats` file
+type hitStats struct {
+	Profiles map[string]map[string]*originStats `json:"profiles"`
+}
+
+type originStats struct {
+	Tries int `json:"tries"`
+	Hits  int `json:"hits"`
+}
+
+// Origin is pruned by `--smart` when tried this many times without a hit
+const smartMinTries = 50
+
+// Loaded stats (updated while scanning and saved at the end)
+var scanStats = hitStats{Profiles: map[string]map[string]*originStats{}}
+
+// Server type of first target (profile used by `--smart` before scan) and framework of source
+var scanServer string
+var scanFramework string
+
+// Load stats file. Missing file is not an error (first scan)
+func loadStats(fpath string) error {
+	scanStats = hitStats{Profiles: map[string]map[string]*originStats{}}
+	if fpath == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(fpath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &scanStats); err != nil {
+		return fmt.Errorf("%s: %v", fpath, err)
+	}
+	if scanStats.Profiles == nil {
+		scanStats.Profiles = map[string]map[string]*originStats{}
+	}
+	return nil
+}
+
+// Save stats file (merged with this scan)
+func saveStats(fpath string) error {
+	if fpath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(scanStats, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fpath, data, 0664)
+}
+
+// "nginx/laravel", "microsoft-iis/-"
+func scanProfile(server string) string {
+	framework := scanFramework
+	if server == "" {
+		server = "-"
+	}
+	if framework == "" {
+		framework = "-"
+	}
+	return server + "/" + framework
+}
+
+// Server type from "Server" response header without version ("Apache/2.4.41 (Ubuntu)" -> "apache")
+func serverName(header string) string {
+	if strings.TrimSpace(header) == "" {
+		return ""
+	}
+	name := strings.Fields(header)[0]
+	return strings.ToLower(strings.SplitN(name, "/", 2)[0])
+}
+
+// Origin of mutation as stats key. Related files are counted by file name
+// because database origin is the same for all of them
+func statsKey(m mutation) string {
+	if strings.HasPrefix(m.origin, "related files database") {
+		return "related [" + filepath.Base(m.fpath) + "]"
+	}
+	return m.origin
+}
+
+// Record one checked mutation on profile of target. Original paths are not counted
+func recordHit(t *target, m mutation, result *scanResult) {
+	if m.origin == "original" || result == nil || result.Err != nil {
+		return
+	}
+
+	scanMutex.Lock()
+	defer scanMutex.Unlock()
+	profile := scanProfile(t.server)
+	if scanStats.Profiles[profile] == nil {
+		scanStats.Profiles[profile] = map[string]*originStats{}
+	}
+	key := statsKey(m)
+	stats := scanStats.Profiles[profile][key]
+	if stats == nil {
+		stats = &originStats{}
+		scanStats.Profiles[profile][key] = stats
+	}
+	stats.Tries++
+	if !result.Skipped && result.Code >= 200 && result.Code < 300 {
+		stats.Hits++
+	}
+}
+
+// Stats of origin for current profile
+// Stats of all profiles are used until current profile has enough tries
+func originStatsFor(key string) originStats {
+	if s := scanStats.Profiles[scanProfile(scanServer)][key]; s != nil && s.Tries >= smartMinTries {
+		return *s
+	}
+
+	var total originStats
+	for _, origins := range scanStats.Profiles {
+		if s := origins[key]; s != nil {
+			total.Tries += s.Tries
+			total.Hits += s.Hits
+		}
+	}
+	return total
+}
+
+// Order mutations by hit rate (most productive first) and drop origins
+// that never hit. Original path is always kept first
+// Not tried origins go before unproductive ones
+func smartMutations(mutations []mutation) []mutation {
+	if !argSmart {
+		return mutations
+	}
+
+	var kept []mutation
+	rates := map[string]float64{}
+	for _, m := range mutations {
+		if m.origin == "original" {
+			kept = append(kept, m)
+			continue
+		}
+		key := statsKey(m)
+		s := originStatsFor(key)
+		if s.Tries >= smartMinTries && s.Hits == 0 {
+			smartPruned++
+			continue
+		}
+		rates[key] = float64(s.Hits+1) / float64(s.Tries+2) // unknown origin ~0.5
+		kept = append(kept, m)
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool {
+		if kept[i].origin == "original" || kept[j].origin == "original" {
+			return kept[i].origin == "original" && kept[j].origin != "original"
+		}
+		return rates[statsKey(kept[i])] > rates[statsKey(kept[j])]
+	})
+	return kept
+}
+
+// Count of mutations dropped by `--smart`
+var smartPruned = 0
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// One endpoint scanned with collected candidates
+// Calibration and detected server behaviour are kept per target
+type target struct {
+	endpoint string
+	host     string // hostname (targets of the same host share request slot)
+	delay    int    // milliseconds between requests
+
+	method             string // HEAD replaced with GET by calibration
+	rangeLimit         int64  // see `--max-body`
+	headFallbackReason string
+	server             string // "Server" header for hit-rate stats profile
+	serverCase         int
+	requestHost        string // Host header sent with requests (vhost mode)
+
+	redirectTargets  map[string]map[string]bool // redirect target -> paths redirected to it
+	softRedirects    map[string]bool            // targets already reported as soft-404
+	vhostHits        map[string]int
+	methodMismatches int
+	hits             int
+	errors           int
+}
+
+// Targets of `--url` and `--targets`
+var targets []*target
+
+// Guards console and results shared by targets scanned in parallel
+var scanMutex sync.Mutex
+
+// Result lines of targets are written here as they come (report file)
+var reportFile io.Writer = ioutil.Discard
+
+func newTarget(endpoint string, delay int) (*target, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid URL [%s] (expected: http(s)://host/path/)", endpoint)
+	}
+
+	// Trailing slash - domain must end with slash
+	// Do not add slash if longer URL given
+	if u.Path == "" {
+		endpoint = strings.TrimSuffix(endpoint, "/") + "/"
+	}
+
+	return &target{
+		endpoint:        endpoint,
+		host:            strings.ToLower(u.Hostname()),
+		delay:           delay,
+		method:          argMethod,
+		rangeLimit:      int64(argMaxBody),
+		serverCase:      caseUnknown,
+		redirectTargets: map[string]map[string]bool{},
+		softRedirects:   map[string]bool{},
+		vhostHits:       map[string]int{},
+	}, nil
+}
+
+// Load targets from flag values and file
+// File has one URL per line with optional delay in milliseconds ("https://a.xx/ 500")
+// Comments "#" ignored, the same endpoint is added once
+func loadTargets(urls []string, fpath string) ([]*target, error) {
+	var list []*target
+	seen := map[string]bool{}
+	add := func(line string) error {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			return nil
+		}
+		delay := argDelay
+		if len(fields) > 1 {
+			n, err := strconv.Atoi(fields[1])
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid delay [%s] for [%s]", fields[1], fields[0])
+			}
+			delay = n
+		}
+		t, err := newTarget(fields[0], delay)
+		if err != nil {
+			return err
+		}
+		if !seen[t.endpoint] {
+			seen[t.endpoint] = true
+			list = append(list, t)
+		}
+		return nil
+	}
+
+	for _, u := range urls {
+		if err := add(u); err != nil {
+			return nil, err
+		}
+	}
+
+	if fpath != "" {
+		f, err := os.Open(fpath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if err := add(scanner.Text()); err != nil {
+				return nil, err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(list) == 0 {
+		return nil, fmt.Errorf("no targets given")
+	}
+	return list, nil
+}
+
+// Check queue on every target
+// Targets of the same host are checked one after another so host never gets parallel requests
+// Up to `--concurrency` hosts are checked at the same time
+func scanTargets() {
+	groups := map[string][]*target{}
+	var hosts []string
+	for _, t := range targets {
+		if groups[t.host] == nil {
+			hosts = append(hosts, t.host)
+		}
+		groups[t.host] = append(groups[t.host], t)
+	}
+
+	slots := make(chan bool, argConcurrency)
+	var wg sync.WaitGroup
+	for _, host := range hosts {
+		wg.Add(1)
+		slots <- true
+		go func(group []*target) {
+			defer wg.Done()
+			for _, t := range group {
+				startTarget(t)
+				checkScanQueue(t)
+			}
+			<-slots
+		}(groups[host])
+	}
+	wg.Wait()
+}
+
+// Per target calibration before its scan
+func startTarget(t *target) {
+	calibrateMethod(t)
+
+	if strings.HasPrefix(t.endpoint, "https") {
+		if cert, err := serverCertificate(t.endpoint); err != nil {
+			t.log(color.RedString("-- %s: server cert: %v --", t.endpoint, err))
+		} else if cert != nil {
+			t.log(color.CyanString("-- %s: server cert: %s --", t.endpoint, certificateInfo(cert)))
+		}
+	}
+}
+
+// Print line and write it to report right away (interrupted scan keeps found results)
+// With many targets report line is prefixed with target endpoint
+func (t *target) log(msg string) {
+	scanMutex.Lock()
+	defer scanMutex.Unlock()
+	clearLine()
+	fmt.Println(msg)
+	if len(targets) > 1 {
+		fmt.Fprintf(reportFile, "[%s] %s\n", t.endpoint, msg)
+	} else {
+		fmt.Fprintln(reportFile, msg)
+	}
+}
+
+// Print skipped result over previous one (not kept in report)
+func printProgress(line string) {
+	scanMutex.Lock()
+	defer scanMutex.Unlock()
+	clearLine()
+	fmt.Print(line)
+	lastLineLength = len(line)
+}
+
+// Cleaning current line with previous line length
+func clearLine() {
+	if lastLineLength == 0 {
+		return
+	}
+	fmt.Printf("\r")
+	fmt.Printf(strings.Repeat(" ", lastLineLength))
+	fmt.Printf("\r")
+	lastLineLength = 0
+}
+
+// Write summary section of every target (in given order) to report and console
+func writeTargetReports() {
+	for _, t := range targets {
+		log.Printf("(TARGET) -- %s [%s]", t.endpoint, t.method)
+		if t.headFallbackReason != "" {
+			log.Printf("(HEAD FALLBACK) -- %s (Range bytes=0-%d)", t.headFallbackReason, t.rangeLimit-1)
+		}
+		if argCrossCheck {
+			log.Printf("(CROSS-CHECK) -- %d hits differ by method", t.methodMismatches)
+		}
+		for _, host := range vhosts {
+			log.Printf("(VHOST) -- %s: %d hits", host, t.vhostHits[host])
+		}
+		log.Printf("(HITS) -- %s: %d hits, %d errors", t.endpoint, t.hits, t.errors)
+	}
+}
+
+// "https://a.xx/, https://b.xx/"
+func targetEndpoints() string {
+	var list []string
+	for _, t := range targets {
+		list = append(list, t.endpoint)
+	}
+	return strings.Join(list, ", ")
+}
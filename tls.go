@@ -0,0 +1,111 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TLS config of all https requests (built from `--tls-*` flags)
+var tlsConfig = &tls.Config{InsecureSkipVerify: true}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Build TLS config: verification with custom CA, client certificate (mTLS), SNI and min version
+func buildTLSConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		InsecureSkipVerify: !argTLSVerify,
+		ServerName:         argSNI,
+	}
+
+	if argTLSMinVersion != "" {
+		version, ok := tlsVersions[strings.TrimSpace(argTLSMinVersion)]
+		if !ok {
+			return nil, fmt.Errorf("unknown TLS version [%s] (expected: 1.0, 1.1, 1.2, 1.3)", argTLSMinVersion)
+		}
+		cfg.MinVersion = version
+	}
+
+	if argCACert != "" {
+		data, err := ioutil.ReadFile(argCACert)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in [%s]", argCACert)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if argClientCert != "" || argClientKey != "" {
+		if argClientCert == "" || argClientKey == "" {
+			return nil, fmt.Errorf("both client certificate and key are needed")
+		}
+		cert, err := tls.LoadX509KeyPair(argClientCert, argClientKey)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// Server certificate of https endpoint (nil for http)
+// Handshake uses the same config as requests so verification errors are shown before scan
+func serverCertificate(endpoint string) (*x509.Certificate, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" {
+		return nil, err
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+	dialer := &net.Dialer{Timeout: time.Duration(argTimeout) * time.Second}
+	cfg := tlsConfig.Clone()
+	if cfg.ServerName == "" {
+		cfg.ServerName = u.Hostname()
+	}
+	conn, err := tls.DialWithDialer(dialer, "tcp", resolveAddr(host), cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate")
+	}
+	return certs[0], nil
+}
+
+// "CN=example.com (SAN: example.com, www.example.com) expires 2020-01-01 (30 days)"
+func certificateInfo(cert *x509.Certificate) string {
+	days := int(time.Until(cert.NotAfter).Hours() / 24)
+	info := fmt.Sprintf("CN=%s", cert.Subject.CommonName)
+	sans := cert.DNSNames
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	if len(sans) > 0 {
+		info += fmt.Sprintf(" (SAN: %s)", strings.Join(sans, ", "))
+	}
+	info += fmt.Sprintf(" issuer CN=%s expires %s (%d days)", cert.Issuer.CommonName, cert.NotAfter.Format("2006-01-02"), days)
+	return info
+}
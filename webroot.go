@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Maps source subtree to URL prefix (relative to endpoint)
+// e.g. "public/=/" serves "public/index.php" as "index.php"
+type webRootMap struct {
+	srcPrefix string // "public/" (empty for whole source)
+	urlPrefix string // "" for endpoint root or "storage/"
+}
+
+// Parsed `--map` values. Empty means whole source served from endpoint root
+var webRootMaps []webRootMap
+
+// Common directories frameworks serve as web root
+// Directory is detected as web root if any of index files found in it
+var webRootCandidates = []string{"public", "web", "public_html", "htdocs", "www", "wwwroot", "html", "webroot"}
+var webRootIndexFiles = []string{"index.php", "index.html", "index.htm", "app.php", "app_dev.php", "index.jsp", "default.aspx"}
+
+// Parse "src/=url/" pairs. Value "auto" detects web root from source
+func parseWebRootMaps(args []string, src scanSource) ([]webRootMap, error) {
+	var maps []webRootMap
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		if arg == "" {
+			continue
+		}
+
+		if arg == "auto" {
+			maps = append(maps, detectWebRoot(src)...)
+			continue
+		}
+
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid mapping [%s] (expected: src/=/url/)", arg)
+		}
+		maps = append(maps, webRootMap{
+			srcPrefix: normalizeMapPrefix(parts[0]),
+			urlPrefix: normalizeMapPrefix(parts[1]),
+		})
+	}
+
+	// longest source prefix first - most specific mapping wins
+	sort.SliceStable(maps, func(i, j int) bool {
+		return len(maps[i].srcPrefix) > len(maps[j].srcPrefix)
+	})
+
+	return maps, nil
+}
+
+// "/public" -> "public/", "/" -> ""
+func normalizeMapPrefix(s string) string {
+	s = strings.Trim(filepath.ToSlash(strings.TrimSpace(s)), "/")
+	if s == "" || s == "." {
+		return ""
+	}
+	return s + "/"
+}
+
+// Find first known web root directory holding index file
+func detectWebRoot(src scanSource) []webRootMap {
+	found := map[string]bool{}
+	src.Walk(func(fpath string, f os.FileInfo, err error) error {
+		if err != nil || fpath == "" {
+			return nil
+		}
+		depth := strings.Count(fpath, "/") + 1
+		if f.IsDir() {
+			if depth > 1 || !inSlice(f.Name(), webRootCandidates) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if depth == 2 && inSlice(f.Name(), webRootIndexFiles) {
+			found[filepath.Dir(fpath)] = true
+		}
+		return nil
+	})
+
+	// keep candidates priority order
+	for _, dir := range webRootCandidates {
+		if found[dir] {
+			return []webRootMap{{srcPrefix: dir + "/", urlPrefix: ""}}
+		}
+	}
+	return nil
+}
+
+// Convert source path to URL path (relative to endpoint)
+// Returns false if path is not served by any mapping
+func mapToURLPath(fpath string) (string, bool) {
+	if len(webRootMaps) == 0 {
+		return fpath, true
+	}
+	for _, m := range webRootMaps {
+		if m.srcPrefix == "" || strings.HasPrefix(fpath, m.srcPrefix) {
+			return m.urlPrefix + strings.TrimPrefix(fpath, m.srcPrefix), true
+		}
+	}
+	return "", false
+}
+
+// Directory is not served but some mapped subtree is below it
+func isWebRootAncestor(fpath string) bool {
+	for _, m := range webRootMaps {
+		if strings.HasPrefix(m.srcPrefix, fpath+"/") {
+			return true
+		}
+	}
+	return false
+}